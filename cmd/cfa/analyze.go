@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewmew/cfa/interval"
+	"github.com/mewmew/cfa/primitive"
+	"github.com/mewmew/cfa/render"
+	"github.com/pkg/errors"
+)
+
+// analyzeCmd recovers the control flow primitives of the given control flow
+// graphs.
+func analyzeCmd(args []string) error {
+	var opts options
+	formats := []string{"json", "pseudo", "decomp"}
+	fs := newFlagSet("analyze", "FILE...", &opts, formats...)
+	var limits interval.Limits
+	fs.IntVar(&limits.MaxDepth, "maxdepth", 0, "maximum length of the derived sequence of graphs (0 = unlimited)")
+	fs.IntVar(&limits.MaxNodes, "maxnodes", 0, "maximum number of nodes in a control flow graph (0 = unlimited)")
+	fs.DurationVar(&limits.Timeout, "timeout", 0, "maximum control flow analysis time per control flow graph (0 = unlimited)")
+	verbose := fs.Bool("v", false, "log the decisions made during control flow analysis")
+	paths, err := parseFlags(fs, args, &opts, formats...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range paths {
+		g, err := parseGraph(path, &opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		var obs interval.Observer
+		if *verbose {
+			obs = interval.NewLogObserver(log.New(os.Stderr, fmt.Sprintf("%s: ", path), 0))
+		}
+		prims := interval.Analyze(context.Background(), g, limits, obs)
+		buf, suffix, err := formatPrims(g, prims, opts.format)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := output(path, suffix, buf, &opts); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// formatPrims returns the control flow primitives recovered from the given
+// control flow graph in the specified output format, and the suffix of the
+// corresponding output file.
+func formatPrims(g *cfg.Graph, prims *primitive.Primitives, format string) ([]byte, string, error) {
+	switch format {
+	case "json":
+		buf, err := json.MarshalIndent(prims, "", "\t")
+		if err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+		return append(buf, '\n'), ".json", nil
+	case "pseudo":
+		return []byte(render.Pseudo(g, prims)), ".pseudo", nil
+	case "decomp":
+		seqs, err := prims.Linearize(g)
+		if err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+		buf, err := json.MarshalIndent(seqs, "", "\t")
+		if err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+		return append(buf, '\n'), "_decomp.json", nil
+	default:
+		panic(fmt.Errorf("support for output format %q not yet implemented", format))
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewkiz/pkg/pathutil"
+	"github.com/mewmew/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+// checkCmd validates the control flow primitives of the given control flow
+// graphs. The primitives of foo.dot are read from foo.json, as located next to
+// the control flow graph by `restructure_interval -o`.
+func checkCmd(args []string) error {
+	var opts options
+	formats := []string{"text"}
+	fs := newFlagSet("check", "FILE...", &opts, formats...)
+	paths, err := parseFlags(fs, args, &opts, formats...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	invalid := 0
+	for _, path := range paths {
+		if path == "-" {
+			return errors.New("unable to locate JSON file of control flow primitives of standard input; specify the control flow graph as a file")
+		}
+		g, err := parseGraph(path, &opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		jsonPath := pathutil.TrimExt(path) + ".json"
+		f, err := os.Open(jsonPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		prims, err := primitive.Decode(f)
+		f.Close()
+		if err != nil {
+			return errors.Errorf("unable to decode primitives of %q; %v", jsonPath, err)
+		}
+		problems, notes := check(g, prims)
+		buf := &bytes.Buffer{}
+		for _, problem := range problems {
+			fmt.Fprintf(buf, "%s: error: %s\n", jsonPath, problem)
+		}
+		for _, note := range notes {
+			fmt.Fprintf(buf, "%s: note: %s\n", jsonPath, note)
+		}
+		if len(problems) == 0 {
+			fmt.Fprintf(buf, "%s: ok\n", jsonPath)
+		} else {
+			invalid++
+		}
+		if err := output(path, ".check", buf.Bytes(), &opts); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if invalid > 0 {
+		return errors.Errorf("invalid control flow primitives in %d of %d files", invalid, len(paths))
+	}
+	return nil
+}
+
+// check validates the control flow primitives of the given control flow graph,
+// and returns the problems found. Notes about valid but noteworthy properties of
+// the primitives (e.g. unstructured control flow) are returned separately.
+func check(g *cfg.Graph, prims *primitive.Primitives) (problems, notes []string) {
+	// Check that every node referenced by a primitive is present in g, after
+	// expanding the nodes of derived graphs.
+	missing := func(kind, head string, names ...string) {
+		for _, name := range names {
+			if len(name) == 0 {
+				continue
+			}
+			for _, n := range prims.Expand(name) {
+				if _, ok := g.NodeWithName(n); !ok {
+					problems = append(problems, fmt.Sprintf("node %q of %s primitive %q not present in control flow graph", n, kind, head))
+				}
+			}
+		}
+	}
+	for _, prim := range prims.Loops {
+		missing("loop", prim.Head, prim.Head, prim.Latch, prim.Follow)
+		missing("loop", prim.Head, prim.Nodes...)
+	}
+	for _, prim := range prims.Switches {
+		missing("switch", prim.Head, prim.Head, prim.Follow)
+		missing("switch", prim.Head, prim.Nodes...)
+	}
+	for _, prim := range prims.Ifs {
+		missing("if", prim.Cond, prim.Cond, prim.Follow)
+		missing("if", prim.Cond, prim.Unresolved...)
+	}
+	if len(problems) > 0 {
+		// Regions are not well-defined for primitives of missing nodes.
+		return problems, notes
+	}
+	// Check that the regions of primitives are properly nested.
+	rs := prims.Regions(g)
+	for i, r := range rs {
+		for _, s := range rs[i+1:] {
+			if r.Overlaps(s) && !r.Contains(s) && !s.Contains(r) {
+				problems = append(problems, fmt.Sprintf("%s primitive %q overlaps %s primitive %q", r.Kind, r.Head, s.Kind, s.Head))
+			}
+		}
+	}
+	if prims.Truncated {
+		notes = append(notes, "control flow analysis truncated; primitives incomplete")
+	}
+	h, mapping, err := prims.Reduce(g)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("unable to reduce control flow graph; %v", err))
+		return problems, notes
+	}
+	if n := h.Nodes().Len(); n > 1 {
+		var names []string
+		for name := range mapping {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		notes = append(notes, fmt.Sprintf("control flow graph not fully structured; %d nodes remaining %v", n, names))
+	}
+	return problems, notes
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ll2goName is the name of the ll2go executable of the ll2go_interval command,
+// which is located through the PATH environment variable.
+const ll2goName = "ll2go_interval"
+
+// decompileCmd decompiles the given LLVM IR assembly files to Go source code.
+//
+// Decompilation is delegated to the ll2go_interval command, as the decompiler
+// is not yet available as a library.
+func decompileCmd(args []string) error {
+	var opts options
+	formats := []string{"go"}
+	fs := newFlagSet("decompile", "FILE.ll...", &opts, formats...)
+	funcs := fs.String("funcs", "", "comma-separated list of functions to decompile")
+	jobs := fs.Int("j", 1, "number of functions to decompile concurrently")
+	quiet := fs.Bool("q", false, "suppress non-error messages")
+	paths, err := parseFlags(fs, args, &opts, formats...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ll2go, err := exec.LookPath(ll2goName)
+	if err != nil {
+		return errors.Errorf("unable to locate %s; install using `go install github.com/mewmew/cfa/cmd/%s`", ll2goName, ll2goName)
+	}
+	for _, path := range paths {
+		ll2goArgs := []string{"-j", strconv.Itoa(*jobs)}
+		if len(opts.entry) > 0 {
+			ll2goArgs = append(ll2goArgs, "-entry", opts.entry)
+		}
+		if len(*funcs) > 0 {
+			ll2goArgs = append(ll2goArgs, "-funcs", *funcs)
+		}
+		if *quiet {
+			ll2goArgs = append(ll2goArgs, "-q")
+		}
+		if path == "-" {
+			// ll2go_interval reads LLVM IR assembly from files only.
+			path = "/dev/stdin"
+		}
+		ll2goArgs = append(ll2goArgs, path)
+		cmd := exec.Command(ll2go, ll2goArgs...)
+		stdout := &bytes.Buffer{}
+		cmd.Stdout = stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return errors.Errorf("unable to decompile %q; %v", path, err)
+		}
+		if err := output(path, ".go", stdout.Bytes(), &opts); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mewmew/cfa/interval"
+	"github.com/mewmew/cfa/render"
+	"github.com/pkg/errors"
+)
+
+// derivedCmd outputs the derived sequence of graphs, G^1...G^n, of the given
+// control flow graphs. The graph G^i of foo.dot is written to foo_G<i>.dot (or
+// foo_G<i>.svg) of the output directory.
+func derivedCmd(args []string) error {
+	var opts options
+	formats := []string{"dot", "svg"}
+	fs := newFlagSet("derived", "FILE...", &opts, formats...)
+	paths, err := parseFlags(fs, args, &opts, formats...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if opts.format == "svg" && len(opts.outDir) == 0 {
+		return errors.New("output directory required for -format=svg; use -o to specify")
+	}
+	for _, path := range paths {
+		g, err := parseGraph(path, &opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		Gs, _ := interval.DerivedSeq(g)
+		for i, Gi := range Gs {
+			var buf []byte
+			switch opts.format {
+			case "dot":
+				buf = []byte(Gi.String() + "\n")
+			case "svg":
+				buf = []byte(render.SVG(Gi, nil))
+			}
+			suffix := fmt.Sprintf("_G%d.%s", i+1, opts.format)
+			if err := output(path, suffix, buf, &opts); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewmew/cfa/interval"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/graph"
+)
+
+// intervalsCmd prints the intervals of the given control flow graphs.
+func intervalsCmd(args []string) error {
+	var opts options
+	formats := []string{"text", "json"}
+	fs := newFlagSet("intervals", "FILE...", &opts, formats...)
+	// derived specifies whether to print the intervals of each derived graph.
+	derived := fs.Bool("derived", false, "print the intervals of each graph of the derived sequence")
+	paths, err := parseFlags(fs, args, &opts, formats...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range paths {
+		g, err := parseGraph(path, &opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		IIs := [][]*interval.Interval{interval.Intervals(g)}
+		if *derived {
+			_, IIs = interval.DerivedSeq(g)
+		}
+		var buf []byte
+		switch opts.format {
+		case "text":
+			buf = intervalsText(IIs)
+		case "json":
+			buf, err = intervalsJSON(IIs)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if err := output(path, ".intervals", buf, &opts); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// jsonInterval is the JSON representation of an interval.
+type jsonInterval struct {
+	// Header node of the interval.
+	Head string `json:"head"`
+	// Nodes of the interval, in reverse post-order.
+	Nodes []string `json:"nodes"`
+}
+
+// intervalsText returns the intervals 𝓘^1...𝓘^n of the derived sequence of
+// graphs in text format; one interval per line.
+func intervalsText(IIs [][]*interval.Interval) []byte {
+	buf := &bytes.Buffer{}
+	for i, Is := range IIs {
+		if len(IIs) > 1 {
+			fmt.Fprintf(buf, "G%d:\n", i+1)
+		}
+		for _, I := range Is {
+			fmt.Fprintln(buf, I)
+		}
+	}
+	return buf.Bytes()
+}
+
+// intervalsJSON returns the intervals 𝓘^1...𝓘^n of the derived sequence of
+// graphs in JSON format; a list of intervals per derived graph.
+func intervalsJSON(IIs [][]*interval.Interval) ([]byte, error) {
+	var v [][]*jsonInterval
+	for _, Is := range IIs {
+		var js []*jsonInterval
+		for _, I := range Is {
+			j := &jsonInterval{Head: I.Head().DOTID()}
+			for _, n := range cfg.SortByRevPost(graph.NodesOf(I.Nodes())) {
+				j.Nodes = append(j.Nodes, n.DOTID())
+			}
+			js = append(js, j)
+		}
+		v = append(v, js)
+	}
+	buf, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(buf, '\n'), nil
+}
@@ -0,0 +1,201 @@
+// The cfa tool performs control flow analysis of control flow graphs, using the
+// interval method.
+//
+// Usage:
+//
+//    cfa COMMAND [OPTION]... FILE...
+//
+// Commands:
+//
+//    intervals   print the intervals of control flow graphs
+//    derived     output the derived sequence of graphs of control flow graphs
+//    analyze     recover the control flow primitives of control flow graphs
+//    decompile   decompile LLVM IR assembly to Go source code
+//    check       validate the control flow primitives of control flow graphs
+//    render      output control flow graphs annotated with their primitives
+//
+// Common flags:
+//
+//    -entry string
+//          label of the entry node (default: node labelled entry, or the single node without incoming edges)
+//    -force
+//          force overwrite existing output files of -o
+//    -format string
+//          output format (default and supported formats depend on the command)
+//    -o string
+//          output directory (default: standard output)
+//
+// Control flow graphs are read from files in any format supported by the
+// reader package, or from standard input if no file is specified.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/mewkiz/pkg/pathutil"
+	"github.com/mewmew/cfa/reader"
+	"github.com/pkg/errors"
+)
+
+// A command is a subcommand of cfa.
+type command struct {
+	// Short description of the command.
+	desc string
+	// Run the command with the given command line arguments.
+	run func(args []string) error
+}
+
+// commands maps from command name to subcommand of cfa.
+var commands map[string]*command
+
+func init() {
+	// Note, commands is initialized by init, as the usage message of each
+	// command refers to commands.
+	commands = map[string]*command{
+		"intervals": {desc: "print the intervals of control flow graphs", run: intervalsCmd},
+		"derived":   {desc: "output the derived sequence of graphs of control flow graphs", run: derivedCmd},
+		"analyze":   {desc: "recover the control flow primitives of control flow graphs", run: analyzeCmd},
+		"decompile": {desc: "decompile LLVM IR assembly to Go source code", run: decompileCmd},
+		"check":     {desc: "validate the control flow primitives of control flow graphs", run: checkCmd},
+		"render":    {desc: "output control flow graphs annotated with their primitives", run: renderCmd},
+	}
+}
+
+func usage() {
+	const use = `
+Perform control flow analysis of control flow graphs, using the interval method.
+
+Usage:
+
+	cfa COMMAND [OPTION]... FILE...
+
+Commands:
+`
+	fmt.Fprintln(os.Stderr, use[1:])
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-11s %s\n", name, commands[name].desc)
+	}
+	fmt.Fprintln(os.Stderr, "\nUse `cfa COMMAND -help` for the flags of each command.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(1)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+// options specifies the common options of the subcommands of cfa.
+type options struct {
+	// Label of the entry node; or empty to use the node labelled "entry", or
+	// the single node without incoming edges.
+	entry string
+	// Force overwrite existing output files of the output directory.
+	force bool
+	// Output format.
+	format string
+	// Output directory; or empty to write to standard output.
+	outDir string
+}
+
+// newFlagSet returns a new flag set of the given command, with the common flags
+// of cfa registered in opts. The first of the supported output formats is the
+// default.
+func newFlagSet(name, args string, opts *options, formats ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s.\n\nUsage:\n\n\tcfa %s [OPTION]... %s\n\nFlags:\n\n", strings.Title(commands[name].desc), name, args)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.entry, "entry", "", "label of the entry node (default: node labelled entry, or the single node without incoming edges)")
+	fs.BoolVar(&opts.force, "force", false, "force overwrite existing output files of -o")
+	fs.StringVar(&opts.format, "format", formats[0], fmt.Sprintf("output format (%s)", strings.Join(formats, ", ")))
+	fs.StringVar(&opts.outDir, "o", "", "output directory (default: standard output)")
+	return fs
+}
+
+// parseFlags parses the command line arguments of the given flag set, and
+// validates the output format against the supported formats. The input files
+// are returned; or "-" (standard input) if no file is specified.
+func parseFlags(fs *flag.FlagSet, args []string, opts *options, formats ...string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	valid := false
+	for _, format := range formats {
+		if opts.format == format {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, errors.Errorf("invalid output format %q of %s; expected %s", opts.format, fs.Name(), strings.Join(formats, ", "))
+	}
+	if fs.NArg() == 0 {
+		return []string{"-"}, nil
+	}
+	return fs.Args(), nil
+}
+
+// parseGraph parses the control flow graph of the given input file, and sets
+// its entry node.
+func parseGraph(path string, opts *options) (*cfg.Graph, error) {
+	g, err := reader.ParseFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := reader.SetEntry(g, opts.entry); err != nil {
+		return nil, errors.Errorf("unable to set entry node of %q; %v; use -entry to specify", path, err)
+	}
+	return g, nil
+}
+
+// output writes the output of the given input file, either to standard output
+// or to the output directory. The name of the output file is the name of the
+// input file, followed by the given suffix (e.g. "foo.dot" -> "foo.json").
+func output(path, suffix string, buf []byte, opts *options) error {
+	if len(opts.outDir) == 0 {
+		if _, err := os.Stdout.Write(buf); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	}
+	name := pathutil.FileName(path)
+	if path == "-" {
+		// Base name of output files of standard input.
+		name = "stdin"
+	}
+	outPath := filepath.Join(opts.outDir, name+suffix)
+	if !opts.force && osutil.Exists(outPath) {
+		return errors.Errorf("output file %q already exists; use -force to overwrite", outPath)
+	}
+	if err := os.MkdirAll(opts.outDir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ioutil.WriteFile(outPath, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testGraph = "testdata/f.dot"
+
+// TestFormats checks that each command rejects unsupported output formats.
+func TestFormats(t *testing.T) {
+	for name, cmd := range commands {
+		err := cmd.run([]string{"-format", "foo", testGraph})
+		want := "invalid output format"
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error mismatch; expected %q, got %v", name, want, err)
+		}
+	}
+}
+
+// TestCommands runs each command in each of its output formats, and checks the
+// names of the output files.
+func TestCommands(t *testing.T) {
+	golden := []struct {
+		cmd    string
+		format string
+		want   []string
+	}{
+		{cmd: "intervals", format: "text", want: []string{"f.intervals"}},
+		{cmd: "intervals", format: "json", want: []string{"f.intervals"}},
+		{cmd: "derived", format: "dot", want: []string{"f_G1.dot", "f_G2.dot", "f_G3.dot"}},
+		{cmd: "derived", format: "svg", want: []string{"f_G1.svg", "f_G2.svg", "f_G3.svg"}},
+		{cmd: "analyze", format: "json", want: []string{"f.json"}},
+		{cmd: "analyze", format: "pseudo", want: []string{"f.pseudo"}},
+		{cmd: "analyze", format: "decomp", want: []string{"f_decomp.json"}},
+		{cmd: "render", format: "dot", want: []string{"f_annotated.dot"}},
+		{cmd: "render", format: "svg", want: []string{"f.svg"}},
+	}
+	for i, gold := range golden {
+		dir, err := ioutil.TempDir("", "cfa_test")
+		if err != nil {
+			t.Fatalf("unable to create temporary directory; %v", err)
+		}
+		defer os.RemoveAll(dir)
+		args := []string{"-format", gold.format, "-o", dir, testGraph}
+		if err := commands[gold.cmd].run(args); err != nil {
+			t.Errorf("i=%d: %s -format=%s; unable to run command; %v", i, gold.cmd, gold.format, err)
+			continue
+		}
+		got := readDirNames(t, dir)
+		if strings.Join(got, " ") != strings.Join(gold.want, " ") {
+			t.Errorf("i=%d: %s -format=%s; output files mismatch; expected %v, got %v", i, gold.cmd, gold.format, gold.want, got)
+		}
+		// Existing output files are only overwritten if -force is set.
+		if err := commands[gold.cmd].run(args); err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("i=%d: %s -format=%s; expected error of existing output file, got %v", i, gold.cmd, gold.format, err)
+		}
+		if err := commands[gold.cmd].run(append([]string{"-force"}, args...)); err != nil {
+			t.Errorf("i=%d: %s -format=%s -force; unable to run command; %v", i, gold.cmd, gold.format, err)
+		}
+	}
+}
+
+// TestCheckCmd checks the primitives output by the analyze command.
+func TestCheckCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfa_test")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory; %v", err)
+	}
+	defer os.RemoveAll(dir)
+	buf, err := ioutil.ReadFile(testGraph)
+	if err != nil {
+		t.Fatalf("%q; unable to read file; %v", testGraph, err)
+	}
+	dotPath := filepath.Join(dir, "f.dot")
+	if err := ioutil.WriteFile(dotPath, buf, 0644); err != nil {
+		t.Fatalf("%q; unable to write file; %v", dotPath, err)
+	}
+	if err := analyzeCmd([]string{"-o", dir, dotPath}); err != nil {
+		t.Fatalf("unable to analyze %q; %v", dotPath, err)
+	}
+	if err := checkCmd([]string{"-o", dir, dotPath}); err != nil {
+		t.Fatalf("unable to check %q; %v", dotPath, err)
+	}
+	checkPath := filepath.Join(dir, "f.check")
+	out, err := ioutil.ReadFile(checkPath)
+	if err != nil {
+		t.Fatalf("%q; unable to read file; %v", checkPath, err)
+	}
+	if want := ": ok\n"; !strings.HasSuffix(string(out), want) {
+		t.Errorf("check output mismatch; expected suffix %q, got %q", want, out)
+	}
+}
+
+// TestEntry checks that the entry node may be specified by label.
+func TestEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfa_test")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory; %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := intervalsCmd([]string{"-entry", "A", "-o", dir, testGraph}); err != nil {
+		t.Errorf("unable to print intervals; %v", err)
+	}
+	err = intervalsCmd([]string{"-entry", "X", "-o", dir, "-force", testGraph})
+	if want := `unable to locate entry node "X"`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error mismatch; expected %q, got %v", want, err)
+	}
+}
+
+// readDirNames returns the sorted file names of the given directory.
+func readDirNames(t *testing.T, dir string) []string {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("%q; unable to read directory; %v", dir, err)
+	}
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	return names
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+
+	"github.com/mewmew/cfa/interval"
+	"github.com/mewmew/cfa/render"
+	"github.com/pkg/errors"
+)
+
+// renderCmd outputs the given control flow graphs annotated with their
+// recovered control flow primitives.
+func renderCmd(args []string) error {
+	var opts options
+	formats := []string{"dot", "svg"}
+	fs := newFlagSet("render", "FILE...", &opts, formats...)
+	paths, err := parseFlags(fs, args, &opts, formats...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range paths {
+		g, err := parseGraph(path, &opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		prims := interval.Analyze(context.Background(), g, interval.Limits{}, nil)
+		var buf []byte
+		var suffix string
+		switch opts.format {
+		case "dot":
+			buf, suffix = []byte(render.DOT(g, prims)), "_annotated.dot"
+		case "svg":
+			buf, suffix = []byte(render.SVG(g, prims)), ".svg"
+		}
+		if err := output(path, suffix, buf, &opts); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
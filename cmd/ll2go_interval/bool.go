@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	irtypes "github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// The 1-bit integer type i1 is represented by bool in the generated Go source
+// code, as it is the result type of comparisons and the condition type of
+// branches and select instructions. Integer operations on i1 values are
+// translated to the corresponding boolean operations, and conversions to and
+// from i1 are translated to comparisons and conditional expressions
+// respectively.
+
+// isBool reports whether the given LLVM IR type is the 1-bit integer type,
+// which is represented by bool in the generated Go source code.
+func isBool(t irtypes.Type) bool {
+	it, ok := t.(*irtypes.IntType)
+	return ok && it.BitSize == 1
+}
+
+// boolOp returns a Go expression of the given binary operation on i1 values;
+// e.g. `x && y` for and, and `x != y` for xor.
+func (d *decompiler) boolOp(x value.Value, op token.Token, y value.Value) ast.Expr {
+	switch op {
+	case token.AND, token.MUL:
+		op = token.LAND
+	case token.OR:
+		op = token.LOR
+	case token.XOR, token.ADD, token.SUB:
+		// Addition and subtraction modulo 2.
+		op = token.NEQ
+	case token.EQL, token.NEQ:
+		// comparison.
+	case token.QUO, token.SHL, token.SHR:
+		// The only valid divisor and shift count is 1 and 0 respectively.
+		return d.value(x)
+	case token.REM:
+		return ast.NewIdent("false")
+	default:
+		panic(fmt.Sprintf("support for boolean operator %v not yet implemented", op))
+	}
+	return &ast.BinaryExpr{
+		X:  paren(d.value(x)),
+		Op: op,
+		Y:  paren(d.value(y)),
+	}
+}
+
+// boolCmp returns a Go expression of the given integer comparison of i1
+// values. True is 1 when compared as unsigned and -1 when compared as signed;
+// e.g. `!x && y` for ult and sgt.
+func (d *decompiler) boolCmp(pred enum.IPred, x, y value.Value) ast.Expr {
+	bx, by := paren(d.value(x)), paren(d.value(y))
+	var expr *ast.BinaryExpr
+	switch pred {
+	case enum.IPredEQ:
+		expr = &ast.BinaryExpr{X: bx, Op: token.EQL, Y: by}
+	case enum.IPredNE:
+		expr = &ast.BinaryExpr{X: bx, Op: token.NEQ, Y: by}
+	case enum.IPredULT, enum.IPredSGT:
+		expr = &ast.BinaryExpr{X: not(bx), Op: token.LAND, Y: by}
+	case enum.IPredUGT, enum.IPredSLT:
+		expr = &ast.BinaryExpr{X: bx, Op: token.LAND, Y: not(by)}
+	case enum.IPredULE, enum.IPredSGE:
+		expr = &ast.BinaryExpr{X: not(bx), Op: token.LOR, Y: by}
+	case enum.IPredUGE, enum.IPredSLE:
+		expr = &ast.BinaryExpr{X: bx, Op: token.LOR, Y: not(by)}
+	default:
+		panic(fmt.Sprintf("support for integer predicate %v not yet implemented", pred))
+	}
+	return expr
+}
+
+// toBool returns a Go expression for truncating the given LLVM IR integer or
+// converting the given floating-point value to i1; e.g. `x&1 != 0`. Floating-
+// point values are truncated toward zero, and thus converted to `f >= 1` if
+// unsigned and to `f <= -1` otherwise.
+func (d *decompiler) toBool(from value.Value, unsigned bool) ast.Expr {
+	x := d.value(from)
+	zero := &ast.BasicLit{Kind: token.INT, Value: "0"}
+	t, ok := from.Type().(*irtypes.IntType)
+	if !ok {
+		one := &ast.BasicLit{Kind: token.INT, Value: "1"}
+		if unsigned {
+			return &ast.BinaryExpr{X: x, Op: token.GEQ, Y: one}
+		}
+		negOne := &ast.UnaryExpr{Op: token.SUB, X: one}
+		return &ast.BinaryExpr{X: x, Op: token.LEQ, Y: negOne}
+	}
+	if isWide(t) {
+		//    x.Bit(0) != 0
+		x = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   x,
+				Sel: ast.NewIdent("Bit"),
+			},
+			Args: []ast.Expr{zero},
+		}
+		return &ast.BinaryExpr{X: x, Op: token.NEQ, Y: zero}
+	}
+	lsb := &ast.BinaryExpr{
+		X:  paren(x),
+		Op: token.AND,
+		Y:  &ast.BasicLit{Kind: token.INT, Value: "1"},
+	}
+	return &ast.BinaryExpr{X: lsb, Op: token.NEQ, Y: zero}
+}
+
+// fromBool returns a Go expression for converting the given i1 value into the
+// specified integer or floating-point type, with true converted to 1 if
+// unsigned and to -1 otherwise.
+//
+//    func() T {
+//       if x {
+//          return -1
+//       }
+//       return 0
+//    }()
+func (d *decompiler) fromBool(from value.Value, to irtypes.Type, unsigned bool) ast.Expr {
+	one := int64(-1)
+	if unsigned {
+		one = 1
+	}
+	var x, y ast.Expr
+	switch t := to.(type) {
+	case *irtypes.IntType:
+		x = d.constInt(constant.NewInt(t, one))
+		y = d.constInt(constant.NewInt(t, 0))
+	case *irtypes.FloatType:
+		x = &ast.BasicLit{Kind: token.INT, Value: "1"}
+		if !unsigned {
+			x = &ast.UnaryExpr{Op: token.SUB, X: x}
+		}
+		y = &ast.BasicLit{Kind: token.INT, Value: "0"}
+	default:
+		// TODO: Add support for conversions of vectors of i1.
+		panic(fmt.Sprintf("support for conversion from %v to %v not yet implemented", from.Type(), to))
+	}
+	return condExpr(d.goType(to), d.value(from), x, y)
+}
+
+// condExpr returns a Go expression of the given type, evaluating to x if cond
+// holds and to y otherwise.
+//
+//    func() T {
+//       if cond {
+//          return x
+//       }
+//       return y
+//    }()
+func condExpr(typ, cond, x, y ast.Expr) ast.Expr {
+	ifStmt := &ast.IfStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{x}}},
+		},
+	}
+	retStmt := &ast.ReturnStmt{
+		Results: []ast.Expr{y},
+	}
+	fn := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{ifStmt, retStmt},
+		},
+	}
+	return &ast.CallExpr{
+		Fun: fn,
+	}
+}
+
+// paren wraps the given Go expression in parentheses if it is a binary
+// expression, to preserve the order of evaluation when used as an operand.
+func paren(x ast.Expr) ast.Expr {
+	if _, ok := x.(*ast.BinaryExpr); ok {
+		return &ast.ParenExpr{X: x}
+	}
+	return x
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	irtypes "github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// ptrToInt returns a Go expression for converting the given LLVM IR pointer
+// into the specified integer type.
+//
+//    intN(uintptr(unsafe.Pointer(p)))
+func (d *decompiler) ptrToInt(from value.Value, to irtypes.Type) ast.Expr {
+	t, ok := to.(*irtypes.IntType)
+	if !ok {
+		// TODO: Add support for ptrtoint of vectors.
+		panic(fmt.Sprintf("support for ptrtoint to %T not yet implemented", to))
+	}
+	addr := &ast.CallExpr{
+		Fun:  ast.NewIdent("uintptr"),
+		Args: []ast.Expr{d.unsafePointer(d.value(from))},
+	}
+	if isWide(t) {
+		//    new(big.Int).SetUint64(uint64(uintptr(unsafe.Pointer(p))))
+		v := &ast.CallExpr{
+			Fun:  ast.NewIdent("uint64"),
+			Args: []ast.Expr{addr},
+		}
+		return d.bigOp("SetUint64", v)
+	}
+	expr := &ast.CallExpr{
+		Fun:  d.goType(t),
+		Args: []ast.Expr{addr},
+	}
+	return d.wrap(t, expr)
+}
+
+// intToPtr returns a Go expression for converting the given LLVM IR integer
+// into the specified pointer type.
+//
+//    (*T)(unsafe.Pointer(uintptr(uintN(x))))
+func (d *decompiler) intToPtr(from value.Value, to irtypes.Type) ast.Expr {
+	t, ok := from.Type().(*irtypes.IntType)
+	if !ok {
+		// TODO: Add support for inttoptr of vectors.
+		panic(fmt.Sprintf("support for inttoptr from %T not yet implemented", from.Type()))
+	}
+	// The integer is zero-extended or truncated to the size of a pointer.
+	x := d.unsigned(from)
+	if isWide(t) {
+		//    uwrapInt(x, N).Uint64()
+		x = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   x,
+				Sel: ast.NewIdent("Uint64"),
+			},
+		}
+	}
+	addr := &ast.CallExpr{
+		Fun:  ast.NewIdent("uintptr"),
+		Args: []ast.Expr{x},
+	}
+	return d.pointerConv(to, d.unsafePointer(addr))
+}
+
+// bitCast returns a Go expression for converting the given LLVM IR value into
+// the specified type, without changing any bits.
+//
+//    (*U)(unsafe.Pointer(p))           // pointer to pointer
+//    int64(math.Float64bits(x))        // floating-point to integer
+//    math.Float64frombits(uint64(x))   // integer to floating-point
+//    *(*U)(unsafe.Pointer(&x))         // other types (e.g. vectors)
+func (d *decompiler) bitCast(from value.Value, to irtypes.Type) ast.Expr {
+	switch fromType := from.Type().(type) {
+	case *irtypes.PointerType:
+		if _, ok := to.(*irtypes.PointerType); ok {
+			// Functions are converted through the address of their function
+			// variable (see funcAddr); e.g. `(*U)(unsafe.Pointer(&f_addr))`.
+			return d.pointerConv(to, d.unsafePointer(d.value(from)))
+		}
+	case *irtypes.FloatType:
+		if t, ok := to.(*irtypes.IntType); ok {
+			//    intN(math.FloatNbits(x))
+			var fn string
+			switch fromType.Kind {
+			case irtypes.FloatKindFloat:
+				fn = "Float32bits"
+			case irtypes.FloatKindDouble:
+				fn = "Float64bits"
+			default:
+				panic(fmt.Sprintf("support for bitcast from floating-point kind %v not yet implemented", fromType.Kind))
+			}
+			bits := &ast.CallExpr{
+				Fun:  d.pkgSelector("math", fn),
+				Args: []ast.Expr{d.value(from)},
+			}
+			return &ast.CallExpr{
+				Fun:  d.goType(t),
+				Args: []ast.Expr{bits},
+			}
+		}
+	case *irtypes.IntType:
+		if t, ok := to.(*irtypes.FloatType); ok {
+			//    math.FloatNfrombits(uintN(x))
+			var fn string
+			switch t.Kind {
+			case irtypes.FloatKindFloat:
+				fn = "Float32frombits"
+			case irtypes.FloatKindDouble:
+				fn = "Float64frombits"
+			default:
+				panic(fmt.Sprintf("support for bitcast to floating-point kind %v not yet implemented", t.Kind))
+			}
+			return &ast.CallExpr{
+				Fun:  d.pkgSelector("math", fn),
+				Args: []ast.Expr{d.unsigned(from)},
+			}
+		}
+		if t, ok := to.(*irtypes.IntType); ok && fromType.BitSize == t.BitSize {
+			// no-op.
+			return d.value(from)
+		}
+	}
+	// Reinterpret the memory of addressable values.
+	if _, ok := from.(value.Named); !ok {
+		panic(fmt.Sprintf("support for bitcast of %T from %v to %v not yet implemented", from, from.Type(), to))
+	}
+	addr := &ast.UnaryExpr{
+		Op: token.AND,
+		X:  d.value(from),
+	}
+	ptr := d.pointerConv(&irtypes.PointerType{ElemType: to}, d.unsafePointer(addr))
+	return &ast.StarExpr{
+		X: ptr,
+	}
+}
+
+// pointerConv returns the Go expression `(*T)(x)` for converting the given
+// unsafe.Pointer into the specified pointer type.
+func (d *decompiler) pointerConv(to irtypes.Type, x ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun:  &ast.ParenExpr{X: d.goType(to)},
+		Args: []ast.Expr{x},
+	}
+}
+
+// unsafePointer returns the Go expression `unsafe.Pointer(x)`.
+func (d *decompiler) unsafePointer(x ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun:  d.pkgSelector("unsafe", "Pointer"),
+		Args: []ast.Expr{x},
+	}
+}
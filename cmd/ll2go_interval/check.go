@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"io"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// llStringer is an LLVM IR instruction or terminator, the definition of which
+// is printed in LLVM IR assembly syntax.
+type llStringer interface {
+	LLString() string
+}
+
+// A typeError is a type error of the generated Go source code, mapped back to
+// the originating LLVM IR function and instruction.
+type typeError struct {
+	// Type error reported by go/types.
+	err types.Error
+	// Position of the type error in the generated Go source code.
+	pos token.Position
+	// Originating LLVM IR function; or nil if outside of function bodies.
+	f *ir.Func
+	// Originating LLVM IR instruction or terminator; or nil if unknown.
+	origin llStringer
+}
+
+// check type-checks the given Go source file, as generated from the given LLVM
+// IR functions, using go/types. A pass/fail summary of each function is written
+// to w, together with the type errors of failing functions. The boolean return
+// value reports whether the source file is free of type errors.
+//
+// Note, the funcs map is keyed by the generated Go function declarations.
+func (d *decompiler) check(w io.Writer, goName string, file *ast.File, funcs map[*ast.FuncDecl]*ir.Func) (bool, error) {
+	// Print and re-parse the source file, to assign positions to its nodes.
+	buf := &bytes.Buffer{}
+	if err := printer.Fprint(buf, token.NewFileSet(), file); err != nil {
+		return false, errors.WithStack(err)
+	}
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, goName, buf.Bytes(), 0)
+	if err != nil {
+		// Syntax errors are reported for the file as a whole.
+		fmt.Fprintf(w, "check: unable to parse generated source code; %v\n", err)
+		return false, nil
+	}
+	var errs []types.Error
+	conf := types.Config{
+		Importer: importer.Default(),
+		Error: func(err error) {
+			if err, ok := err.(types.Error); ok {
+				errs = append(errs, err)
+			}
+		},
+	}
+	// Type errors are recorded by conf.Error.
+	_, _ = conf.Check(parsed.Name.Name, fset, []*ast.File{parsed}, nil)
+
+	// Map type errors back to the originating LLVM IR functions and
+	// instructions. The statements of the re-parsed source file correspond to
+	// the statements of the generated source file, in pre-order.
+	genStmts, parsedStmts := stmtsOf(file), stmtsOf(parsed)
+	if len(genStmts) != len(parsedStmts) {
+		// Leave instructions unmapped.
+		parsedStmts = nil
+	}
+	var terrs []*typeError
+	for _, err := range errs {
+		terr := &typeError{err: err, pos: fset.Position(err.Pos)}
+		for i, decl := range parsed.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= err.Pos && err.Pos < fn.End() {
+				terr.f = funcs[file.Decls[i].(*ast.FuncDecl)]
+				break
+			}
+		}
+		// Locate the innermost statement of known origin enclosing the error.
+		for i, stmt := range parsedStmts {
+			if stmt.Pos() <= err.Pos && err.Pos < stmt.End() {
+				if origin, ok := d.origins[genStmts[i]]; ok {
+					terr.origin = origin
+				}
+			}
+		}
+		terrs = append(terrs, terr)
+	}
+
+	// Print pass/fail summary.
+	printErrs := func(f *ir.Func) {
+		for _, terr := range terrs {
+			if terr.f != f {
+				continue
+			}
+			fmt.Fprintf(w, "\t%v: %s\n", terr.pos, terr.err.Msg)
+			if terr.origin != nil {
+				fmt.Fprintf(w, "\t\tin LLVM IR `%s`\n", strings.TrimSpace(terr.origin.LLString()))
+			}
+		}
+	}
+	nerrs := func(f *ir.Func) int {
+		n := 0
+		for _, terr := range terrs {
+			if terr.f == f {
+				n++
+			}
+		}
+		return n
+	}
+	if n := nerrs(nil); n > 0 {
+		fmt.Fprintf(w, "check: global declarations: FAIL (%d errors)\n", n)
+		printErrs(nil)
+	}
+	total, passed := 0, 0
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		f, ok := funcs[fn]
+		if !ok {
+			// Skip helper functions (e.g. newIntNNN).
+			continue
+		}
+		total++
+		if n := nerrs(f); n > 0 {
+			fmt.Fprintf(w, "check: function %s: FAIL (%d errors)\n", f.Ident(), n)
+			printErrs(f)
+			continue
+		}
+		passed++
+		fmt.Fprintf(w, "check: function %s: ok\n", f.Ident())
+	}
+	fmt.Fprintf(w, "check: %d of %d functions passed\n", passed, total)
+	return len(terrs) == 0, nil
+}
+
+// stmtsOf returns the statements of the given source file, in pre-order.
+func stmtsOf(file *ast.File) []ast.Stmt {
+	var stmts []ast.Stmt
+	ast.Inspect(file, func(n ast.Node) bool {
+		if stmt, ok := n.(ast.Stmt); ok {
+			stmts = append(stmts, stmt)
+		}
+		return true
+	})
+	return stmts
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/llir/llvm/ir"
+)
+
+// inst is a mock LLVM IR instruction.
+type inst string
+
+func (i inst) LLString() string { return string(i) }
+
+func TestCheck(t *testing.T) {
+	const src = `package p
+
+func f() int32 {
+	var x int32
+	x = y + 1
+	return x
+}
+
+func g() int32 {
+	return 42
+}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "p.go", src, 0)
+	if err != nil {
+		t.Fatalf("unable to parse source; %v", err)
+	}
+	d := newDecompiler()
+	funcs := make(map[*ast.FuncDecl]*ir.Func)
+	for _, decl := range file.Decls {
+		fn := decl.(*ast.FuncDecl)
+		f := &ir.Func{}
+		f.GlobalName = fn.Name.Name
+		funcs[fn] = f
+	}
+	// Record origin of `x = y + 1`.
+	assignStmt := file.Decls[0].(*ast.FuncDecl).Body.List[1]
+	d.origins[assignStmt] = inst("%x = add i32 %y, 1")
+	buf := &bytes.Buffer{}
+	ok, err := d.check(buf, "p.go", file, funcs)
+	if err != nil {
+		t.Fatalf("unable to type-check source; %v", err)
+	}
+	if ok {
+		t.Errorf("type errors not reported")
+	}
+	out := buf.String()
+	for _, want := range []string{"FAIL (1 errors)", "p.go:5:6: ", "in LLVM IR `%x = add i32 %y, 1`", "1 of 2 functions passed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q; got:\n%s", want, out)
+		}
+	}
+}
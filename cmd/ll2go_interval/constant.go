@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"math/big"
+	"strconv"
 
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
 )
 
 // constant converts the given LLVM IR constant to a corresponding Go
@@ -31,11 +34,14 @@ func (d *decompiler) constant(c constant.Constant) ast.Expr {
 		return d.constStruct(c)
 	case *constant.ZeroInitializer:
 		return d.constZeroInitializer(c)
+	// Undefined values
+	case *constant.Undef:
+		return d.constUndef(c)
 	// Global variable and function addresses
 	case *ir.Global:
 		return d.globalIdent(c.GlobalName)
 	case *ir.Func:
-		return d.globalIdent(c.GlobalName)
+		return d.funcAddr(c)
 	// Constant expressions
 	case constant.Expression:
 		return d.expr(c)
@@ -47,9 +53,25 @@ func (d *decompiler) constant(c constant.Constant) ast.Expr {
 // constInt converts the given LLVM IR integer constant to a corresponding Go
 // expression.
 func (d *decompiler) constInt(c *constant.Int) ast.Expr {
+	if isBool(c.Typ) {
+		if c.X.Sign() != 0 {
+			return ast.NewIdent("true")
+		}
+		return ast.NewIdent("false")
+	}
+	// Integer constants are sign-extended, as values of non-builtin integer
+	// types are kept sign-extended.
+	x := c.X
+	m := new(big.Int).Lsh(big.NewInt(1), uint(c.Typ.BitSize))
+	if x.Sign() >= 0 && x.BitLen() >= int(c.Typ.BitSize) {
+		x = new(big.Int).Sub(x, m)
+	}
+	if isWide(c.Typ) {
+		return d.wideConst(x)
+	}
 	return &ast.BasicLit{
 		Kind:  token.INT,
-		Value: c.X.String(),
+		Value: x.String(),
 	}
 }
 
@@ -95,12 +117,20 @@ func (d *decompiler) constArray(c *constant.Array) ast.Expr {
 }
 
 // constCharArray converts the given LLVM IR character array constant to a
-// corresponding Go expression.
+// corresponding Go expression; an array of int8 elements, the address of which
+// may be taken (e.g. by getelementptr into string tables).
 func (d *decompiler) constCharArray(c *constant.CharArray) ast.Expr {
-	s := string(c.X)
-	return &ast.BasicLit{
-		Kind:  token.STRING,
-		Value: fmt.Sprintf("%q", s),
+	var elems []ast.Expr
+	for _, b := range c.X {
+		elem := &ast.BasicLit{
+			Kind:  token.INT,
+			Value: strconv.Itoa(int(int8(b))),
+		}
+		elems = append(elems, elem)
+	}
+	return &ast.CompositeLit{
+		Type: d.goType(c.Typ),
+		Elts: elems,
 	}
 }
 
@@ -132,6 +162,19 @@ func (d *decompiler) constZeroInitializer(c *constant.ZeroInitializer) ast.Expr
 	}
 }
 
+// constUndef converts the given LLVM IR undefined value to a corresponding Go
+// expression; the zero value of its type.
+func (d *decompiler) constUndef(c *constant.Undef) ast.Expr {
+	//    *new(T)
+	expr := &ast.CallExpr{
+		Fun:  ast.NewIdent("new"),
+		Args: []ast.Expr{d.goType(c.Typ)},
+	}
+	return &ast.StarExpr{
+		X: expr,
+	}
+}
+
 // expr converts the given LLVM IR expression to a corresponding Go expression.
 func (d *decompiler) expr(expr constant.Expression) ast.Expr {
 	switch expr := expr.(type) {
@@ -254,7 +297,7 @@ func (d *decompiler) exprFMul(expr *constant.ExprFMul) ast.Expr {
 // exprUDiv converts the given LLVM IR udiv expression to a corresponding Go
 // statement.
 func (d *decompiler) exprUDiv(expr *constant.ExprUDiv) ast.Expr {
-	return d.binaryOp(expr.X, token.QUO, expr.Y)
+	return d.unsignedBinaryOp(expr.X, token.QUO, expr.Y)
 }
 
 // exprSDiv converts the given LLVM IR sdiv expression to a corresponding Go
@@ -272,7 +315,7 @@ func (d *decompiler) exprFDiv(expr *constant.ExprFDiv) ast.Expr {
 // exprURem converts the given LLVM IR urem expression to a corresponding Go
 // statement.
 func (d *decompiler) exprURem(expr *constant.ExprURem) ast.Expr {
-	return d.binaryOp(expr.X, token.REM, expr.Y)
+	return d.unsignedBinaryOp(expr.X, token.REM, expr.Y)
 }
 
 // exprSRem converts the given LLVM IR srem expression to a corresponding Go
@@ -290,19 +333,19 @@ func (d *decompiler) exprFRem(expr *constant.ExprFRem) ast.Expr {
 // exprShl converts the given LLVM IR shl expression to a corresponding Go
 // statement.
 func (d *decompiler) exprShl(expr *constant.ExprShl) ast.Expr {
-	return d.binaryOp(expr.X, token.SHL, expr.Y)
+	return d.shiftOp(expr.X, token.SHL, expr.Y)
 }
 
 // exprLShr converts the given LLVM IR lshr expression to a corresponding Go
 // statement.
 func (d *decompiler) exprLShr(expr *constant.ExprLShr) ast.Expr {
-	return d.binaryOp(expr.X, token.SHR, expr.Y)
+	return d.unsignedBinaryOp(expr.X, token.SHR, expr.Y)
 }
 
 // exprAShr converts the given LLVM IR ashr expression to a corresponding Go
 // statement.
 func (d *decompiler) exprAShr(expr *constant.ExprAShr) ast.Expr {
-	return d.binaryOp(expr.X, token.SHR, expr.Y)
+	return d.shiftOp(expr.X, token.SHR, expr.Y)
 }
 
 // exprAnd converts the given LLVM IR and expression to a corresponding Go
@@ -326,19 +369,11 @@ func (d *decompiler) exprXor(expr *constant.ExprXor) ast.Expr {
 // exprGetElementPtr converts the given LLVM IR getelementptr expression to a
 // corresponding Go statement.
 func (d *decompiler) exprGetElementPtr(expr *constant.ExprGetElementPtr) ast.Expr {
-	src := d.constant(expr.Src)
-	// TODO: Validate if index expressions should be added in reverse order.
+	var indices []value.Value
 	for _, index := range expr.Indices {
-		src = &ast.IndexExpr{
-			X:     src,
-			Index: d.constant(index),
-		}
+		indices = append(indices, index)
 	}
-	e := &ast.UnaryExpr{
-		Op: token.AND,
-		X:  src,
-	}
-	return e
+	return d.gep(expr.ElemType, expr.Src, indices)
 }
 
 // exprTrunc converts the given LLVM IR trunc expression to a corresponding Go
@@ -350,7 +385,7 @@ func (d *decompiler) exprTrunc(expr *constant.ExprTrunc) ast.Expr {
 // exprZExt converts the given LLVM IR zext expression to a corresponding Go
 // statement.
 func (d *decompiler) exprZExt(expr *constant.ExprZExt) ast.Expr {
-	return d.convert(expr.From, expr.To)
+	return d.convertUnsigned(expr.From, expr.To)
 }
 
 // exprSExt converts the given LLVM IR sext expression to a corresponding Go
@@ -374,7 +409,7 @@ func (d *decompiler) exprFPExt(expr *constant.ExprFPExt) ast.Expr {
 // exprFPToUI converts the given LLVM IR fptoui expression to a corresponding Go
 // statement.
 func (d *decompiler) exprFPToUI(expr *constant.ExprFPToUI) ast.Expr {
-	return d.convert(expr.From, expr.To)
+	return d.convertToUnsigned(expr.From, expr.To)
 }
 
 // exprFPToSI converts the given LLVM IR fptosi expression to a corresponding Go
@@ -386,7 +421,7 @@ func (d *decompiler) exprFPToSI(expr *constant.ExprFPToSI) ast.Expr {
 // exprUIToFP converts the given LLVM IR uitofp expression to a corresponding Go
 // statement.
 func (d *decompiler) exprUIToFP(expr *constant.ExprUIToFP) ast.Expr {
-	return d.convert(expr.From, expr.To)
+	return d.convertUnsigned(expr.From, expr.To)
 }
 
 // exprSIToFP converts the given LLVM IR sitofp expression to a corresponding Go
@@ -398,32 +433,32 @@ func (d *decompiler) exprSIToFP(expr *constant.ExprSIToFP) ast.Expr {
 // exprPtrToInt converts the given LLVM IR ptrtoint expression to a
 // corresponding Go statement.
 func (d *decompiler) exprPtrToInt(expr *constant.ExprPtrToInt) ast.Expr {
-	return d.convert(expr.From, expr.To)
+	return d.ptrToInt(expr.From, expr.To)
 }
 
 // exprIntToPtr converts the given LLVM IR inttoptr expression to a
 // corresponding Go statement.
 func (d *decompiler) exprIntToPtr(expr *constant.ExprIntToPtr) ast.Expr {
-	return d.convert(expr.From, expr.To)
+	return d.intToPtr(expr.From, expr.To)
 }
 
 // exprBitCast converts the given LLVM IR bitcast expression to a corresponding
 // Go statement.
 func (d *decompiler) exprBitCast(expr *constant.ExprBitCast) ast.Expr {
-	return d.convert(expr.From, expr.To)
+	return d.bitCast(expr.From, expr.To)
 }
 
 // exprAddrSpaceCast converts the given LLVM IR addrspacecast expression to a
 // corresponding Go statement.
 func (d *decompiler) exprAddrSpaceCast(expr *constant.ExprAddrSpaceCast) ast.Expr {
-	return d.convert(expr.From, expr.To)
+	// Address spaces are not represented in Go.
+	return d.bitCast(expr.From, expr.To)
 }
 
 // exprICmp converts the given LLVM IR icmp expression to a corresponding Go
 // statement.
 func (d *decompiler) exprICmp(expr *constant.ExprICmp) ast.Expr {
-	op := ipred(expr.Pred)
-	return d.binaryOp(expr.X, op, expr.Y)
+	return d.icmp(expr.Pred, expr.X, expr.Y)
 }
 
 // exprFCmp converts the given LLVM IR fcmp expression to a corresponding Go
@@ -435,6 +470,16 @@ func (d *decompiler) exprFCmp(expr *constant.ExprFCmp) ast.Expr {
 
 // exprSelect converts the given LLVM IR select expression to a corresponding Go
 // statement.
+//
+// Go has no conditional expressions, thus a function literal is used.
+//
+//    func() T {
+//       if cond {
+//          return x
+//       }
+//       return y
+//    }()
 func (d *decompiler) exprSelect(expr *constant.ExprSelect) ast.Expr {
-	panic("not yet implemented")
+	typ := d.goType(expr.X.Type())
+	return condExpr(typ, d.constant(expr.Cond), d.constant(expr.X), d.constant(expr.Y))
 }
@@ -0,0 +1,659 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// fixGotos repairs the goto-statements of the given function declaration which
+// are illegal in Go; i.e. goto-statements jumping over variable declarations
+// or into blocks.
+//
+// Variable declarations are hoisted to the top of the function body. Top-level
+// statements containing labels targeted by jumps into blocks are lowered to
+// goto-statements, thus lifting the labels into the function body. Labels no
+// longer targeted are removed.
+func fixGotos(fn *ast.FuncDecl) error {
+	if fn.Body == nil {
+		return nil
+	}
+	if err := hoistDecls(fn.Body); err != nil {
+		return errors.WithStack(err)
+	}
+	illegal, err := illegalLabels(fn.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(illegal) > 0 {
+		l := newLowerer(fn.Body)
+		var stmts []ast.Stmt
+		for _, stmt := range fn.Body.List {
+			if !hasLabel(stmt, illegal) {
+				stmts = append(stmts, stmt)
+				continue
+			}
+			lowered, err := l.lower(stmt, "", "")
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			stmts = append(stmts, lowered...)
+		}
+		fn.Body.List = attachLabels(stmts)
+		// Validate that all jumps are legal after lowering.
+		illegal, err := illegalLabels(fn.Body)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(illegal) > 0 {
+			return errors.Errorf("unable to repair jumps to labels %v", sortedKeys(illegal))
+		}
+	}
+	pruneLabels(fn.Body)
+	return nil
+}
+
+// ### [ Hoisting ] ############################################################
+
+// hoistDecls hoists the variable declarations of the given function body to
+// the top of the function body. Declarations with initial values are replaced
+// by assignments, and declarations without by assignments of the zero value,
+// as variables are re-initialized each time their declaration is executed.
+//
+// Short variable declarations are left as is, since their types are not known.
+func hoistDecls(body *ast.BlockStmt) error {
+	// Leading variable declaration of the function body, as declared by
+	// d.localDecl.
+	var top *ast.GenDecl
+	declared := make(map[string]bool)
+	if len(body.List) > 0 {
+		if decl, ok := body.List[0].(*ast.DeclStmt); ok {
+			if gen, ok := decl.Decl.(*ast.GenDecl); ok && gen.Tok == token.VAR {
+				top = gen
+				for _, spec := range gen.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						declared[name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	var specs []ast.Spec
+	var err error
+	// hoist returns the statements replacing the given variable declaration.
+	hoist := func(decl *ast.GenDecl) []ast.Stmt {
+		var stmts []ast.Stmt
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.ValueSpec)
+			if spec.Type == nil {
+				err = errors.Errorf("unable to hoist declaration of variables %v with unknown type", spec.Names)
+				return []ast.Stmt{&ast.DeclStmt{Decl: decl}}
+			}
+			for i, name := range spec.Names {
+				if declared[name.Name] {
+					err = errors.Errorf("unable to hoist declaration of variable %q; already declared", name.Name)
+					return []ast.Stmt{&ast.DeclStmt{Decl: decl}}
+				}
+				declared[name.Name] = true
+				specs = append(specs, &ast.ValueSpec{
+					Names: []*ast.Ident{ast.NewIdent(name.Name)},
+					Type:  spec.Type,
+				})
+				// x = *new(T)
+				var value ast.Expr = &ast.StarExpr{
+					X: &ast.CallExpr{
+						Fun:  ast.NewIdent("new"),
+						Args: []ast.Expr{spec.Type},
+					},
+				}
+				if i < len(spec.Values) {
+					value = spec.Values[i]
+				}
+				assignStmt := &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(name.Name)},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{value},
+				}
+				stmts = append(stmts, assignStmt)
+			}
+		}
+		return stmts
+	}
+	var hoistList func(stmts []ast.Stmt) []ast.Stmt
+	hoistList = func(stmts []ast.Stmt) []ast.Stmt {
+		var list []ast.Stmt
+		for _, stmt := range stmts {
+			if decl, ok := stmt.(*ast.DeclStmt); ok {
+				if gen, ok := decl.Decl.(*ast.GenDecl); ok && gen.Tok == token.VAR && gen != top {
+					list = append(list, hoist(gen)...)
+					continue
+				}
+			}
+			forEachList(stmt, hoistList)
+			list = append(list, stmt)
+		}
+		return list
+	}
+	body.List = hoistList(body.List)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+	if top == nil {
+		top = &ast.GenDecl{Tok: token.VAR}
+		body.List = append([]ast.Stmt{&ast.DeclStmt{Decl: top}}, body.List...)
+	}
+	top.Specs = append(top.Specs, specs...)
+	return nil
+}
+
+// forEachList replaces each statement list directly nested within the given
+// statement by the result of f.
+func forEachList(stmt ast.Stmt, f func(stmts []ast.Stmt) []ast.Stmt) {
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		stmt.List = f(stmt.List)
+	case *ast.LabeledStmt:
+		forEachList(stmt.Stmt, f)
+	case *ast.IfStmt:
+		forEachList(stmt.Body, f)
+		if stmt.Else != nil {
+			forEachList(stmt.Else, f)
+		}
+	case *ast.ForStmt:
+		forEachList(stmt.Body, f)
+	case *ast.RangeStmt:
+		forEachList(stmt.Body, f)
+	case *ast.SwitchStmt:
+		forEachList(stmt.Body, f)
+	case *ast.TypeSwitchStmt:
+		forEachList(stmt.Body, f)
+	case *ast.SelectStmt:
+		forEachList(stmt.Body, f)
+	case *ast.CaseClause:
+		stmt.Body = f(stmt.Body)
+	case *ast.CommClause:
+		stmt.Body = f(stmt.Body)
+	}
+}
+
+// ### [ Validation ] ##########################################################
+
+// A scope is a statement list of a block, as part of the chain of enclosing
+// blocks of a statement.
+type scope struct {
+	// Statements of the block.
+	stmts []ast.Stmt
+	// Index of the statement of the block which encloses the statement.
+	index int
+}
+
+// illegalLabels returns the set of labels targeted by illegal goto-statements
+// of the given function body; i.e. labels of blocks not enclosing the goto-
+// statement, or labels preceded by variable declarations which are not in scope
+// at the goto-statement.
+func illegalLabels(body *ast.BlockStmt) (map[string]bool, error) {
+	// Map from label name to the chain of enclosing blocks of the label.
+	labels := make(map[string][]scope)
+	// Map from goto-statement to the chain of enclosing blocks of the statement.
+	gotos := make(map[*ast.BranchStmt][]scope)
+	var walk func(stmts []ast.Stmt, chain []scope)
+	walk = func(stmts []ast.Stmt, chain []scope) {
+		for i, stmt := range stmts {
+			c := append(chain[:len(chain):len(chain)], scope{stmts: stmts, index: i})
+			for {
+				labeled, ok := stmt.(*ast.LabeledStmt)
+				if !ok {
+					break
+				}
+				labels[labeled.Label.Name] = c
+				stmt = labeled.Stmt
+			}
+			if branch, ok := stmt.(*ast.BranchStmt); ok && branch.Tok == token.GOTO {
+				gotos[branch] = c
+			}
+			forEachList(stmt, func(stmts []ast.Stmt) []ast.Stmt {
+				walk(stmts, c)
+				return stmts
+			})
+		}
+	}
+	walk(body.List, nil)
+	illegal := make(map[string]bool)
+	for branch, gotoChain := range gotos {
+		labelChain, ok := labels[branch.Label.Name]
+		if !ok {
+			return nil, errors.Errorf("unable to locate label %q of goto-statement", branch.Label.Name)
+		}
+		// The block of the label must enclose the goto-statement.
+		target := labelChain[len(labelChain)-1]
+		depth := len(labelChain) - 1
+		if depth >= len(gotoChain) || !sameList(gotoChain[depth].stmts, target.stmts) {
+			illegal[branch.Label.Name] = true
+			continue
+		}
+		// Forward jumps must not jump over variable declarations.
+		for i := gotoChain[depth].index + 1; i < target.index; i++ {
+			if isDecl(target.stmts[i]) {
+				illegal[branch.Label.Name] = true
+				break
+			}
+		}
+	}
+	return illegal, nil
+}
+
+// sameList reports whether the given statement lists are the same list.
+func sameList(a, b []ast.Stmt) bool {
+	return len(a) > 0 && len(b) > 0 && &a[0] == &b[0]
+}
+
+// isDecl reports whether the given statement declares variables.
+func isDecl(stmt ast.Stmt) bool {
+	for {
+		labeled, ok := stmt.(*ast.LabeledStmt)
+		if !ok {
+			break
+		}
+		stmt = labeled.Stmt
+	}
+	switch stmt := stmt.(type) {
+	case *ast.DeclStmt:
+		gen, ok := stmt.Decl.(*ast.GenDecl)
+		return ok && gen.Tok == token.VAR
+	case *ast.AssignStmt:
+		return stmt.Tok == token.DEFINE
+	}
+	return false
+}
+
+// hasLabel reports whether the given statement contains any of the given
+// labels.
+func hasLabel(stmt ast.Stmt, labels map[string]bool) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.LabeledStmt:
+			if labels[n.Label.Name] {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// ### [ Lowering ] #############################################################
+
+// A lowerer lowers structured statements to goto-statements.
+type lowerer struct {
+	// Labels in use by the function.
+	labels map[string]bool
+	// Map from label of lowered loop to the labels of its continue and break
+	// targets.
+	loops map[string][2]string
+}
+
+// newLowerer returns a new lowerer of statements of the given function body.
+func newLowerer(body *ast.BlockStmt) *lowerer {
+	l := &lowerer{
+		labels: make(map[string]bool),
+		loops:  make(map[string][2]string),
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if labeled, ok := n.(*ast.LabeledStmt); ok {
+			l.labels[labeled.Label.Name] = true
+		}
+		return true
+	})
+	return l
+}
+
+// newLabel returns a new unique label of the given kind.
+func (l *lowerer) newLabel(kind string) string {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("_%s_%d", kind, i)
+		if !l.labels[name] {
+			l.labels[name] = true
+			return name
+		}
+	}
+}
+
+// lower lowers the given statement to a list of statements in which structured
+// statements (blocks, if-statements and for-loops) are replaced by goto-
+// statements. The labels of unlabelled continue and break targets of the
+// innermost enclosing loop are specified by cont and brk, if lowered.
+func (l *lowerer) lower(stmt ast.Stmt, cont, brk string) ([]ast.Stmt, error) {
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		return l.lowerList(stmt.List, cont, brk)
+	case *ast.LabeledStmt:
+		// A statement may have several labels; e.g. `L: M: for {}`.
+		var labels []ast.Stmt
+		var names []string
+		var inner ast.Stmt = stmt
+		for {
+			labeled, ok := inner.(*ast.LabeledStmt)
+			if !ok {
+				break
+			}
+			labels = append(labels, labelStmt(labeled.Label.Name))
+			names = append(names, labeled.Label.Name)
+			inner = labeled.Stmt
+		}
+		var stmts []ast.Stmt
+		if loop, ok := inner.(*ast.ForStmt); ok {
+			// Labelled continue and break statements of the loop target the
+			// lowered loop.
+			lowered, err := l.lowerFor(loop, names...)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			stmts = lowered
+		} else {
+			// Labelled break statements of other statements (e.g. switch-
+			// statements) target the statement itself, which is kept.
+			lowered, err := l.lower(inner, cont, brk)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			stmts = lowered
+		}
+		return append(labels, stmts...), nil
+	case *ast.IfStmt:
+		return l.lowerIf(stmt, cont, brk)
+	case *ast.ForStmt:
+		return l.lowerFor(stmt)
+	case *ast.BranchStmt:
+		return []ast.Stmt{l.lowerBranch(stmt, cont, brk)}, nil
+	case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.RangeStmt:
+		// Statements not lowered; unlabelled break statements within refer to
+		// the statement itself, and unlabelled continue statements within range
+		// loops to the range loop.
+		_, isRange := stmt.(*ast.RangeStmt)
+		if isRange {
+			cont = ""
+		}
+		l.rewriteBranches(stmt, cont)
+		return []ast.Stmt{stmt}, nil
+	default:
+		return []ast.Stmt{stmt}, nil
+	}
+}
+
+// lowerList lowers the given list of statements.
+func (l *lowerer) lowerList(stmts []ast.Stmt, cont, brk string) ([]ast.Stmt, error) {
+	var list []ast.Stmt
+	for _, stmt := range stmts {
+		lowered, err := l.lower(stmt, cont, brk)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		list = append(list, lowered...)
+	}
+	return list, nil
+}
+
+// lowerIf lowers the given if-statement.
+//
+//    if !(cond) {
+//       goto else
+//    }
+//    body
+//    goto end
+//    else:
+//    else body
+//    end:
+func (l *lowerer) lowerIf(stmt *ast.IfStmt, cont, brk string) ([]ast.Stmt, error) {
+	var stmts []ast.Stmt
+	if stmt.Init != nil {
+		if isDecl(stmt.Init) {
+			return nil, errors.New("unable to lower if-statement with short variable declaration")
+		}
+		stmts = append(stmts, stmt.Init)
+	}
+	end := l.newLabel("if_end")
+	target := end
+	var elseLabel string
+	if stmt.Else != nil {
+		elseLabel = l.newLabel("if_else")
+		target = elseLabel
+	}
+	stmts = append(stmts, condGoto(not(stmt.Cond), target))
+	body, err := l.lower(stmt.Body, cont, brk)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	stmts = append(stmts, body...)
+	if stmt.Else != nil {
+		stmts = append(stmts, gotoStmt(end), labelStmt(elseLabel))
+		elseBody, err := l.lower(stmt.Else, cont, brk)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		stmts = append(stmts, elseBody...)
+	}
+	stmts = append(stmts, labelStmt(end))
+	return stmts, nil
+}
+
+// lowerFor lowers the given for-loop, with the given labels if labelled.
+//
+//    init
+//    top:
+//    if !(cond) {
+//       goto end
+//    }
+//    body
+//    cont:
+//    post
+//    goto top
+//    end:
+func (l *lowerer) lowerFor(stmt *ast.ForStmt, labels ...string) ([]ast.Stmt, error) {
+	var stmts []ast.Stmt
+	if stmt.Init != nil {
+		if isDecl(stmt.Init) {
+			return nil, errors.New("unable to lower for-loop with short variable declaration")
+		}
+		stmts = append(stmts, stmt.Init)
+	}
+	top := l.newLabel("for_top")
+	cont := l.newLabel("for_cont")
+	end := l.newLabel("for_end")
+	for _, label := range labels {
+		l.loops[label] = [2]string{cont, end}
+	}
+	stmts = append(stmts, labelStmt(top))
+	if stmt.Cond != nil {
+		stmts = append(stmts, condGoto(not(stmt.Cond), end))
+	}
+	body, err := l.lower(stmt.Body, cont, end)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	stmts = append(stmts, body...)
+	stmts = append(stmts, labelStmt(cont))
+	if stmt.Post != nil {
+		stmts = append(stmts, stmt.Post)
+	}
+	stmts = append(stmts, gotoStmt(top), labelStmt(end))
+	return stmts, nil
+}
+
+// lowerBranch lowers the given branch statement, which targets a lowered loop
+// if unlabelled and cont or brk is non-empty, or if labelled by a lowered loop.
+// Branch statements labelled by other statements are left as is, as their
+// targets are not lowered.
+func (l *lowerer) lowerBranch(stmt *ast.BranchStmt, cont, brk string) ast.Stmt {
+	if stmt.Label != nil {
+		loop, ok := l.loops[stmt.Label.Name]
+		if !ok {
+			return stmt
+		}
+		cont, brk = loop[0], loop[1]
+	}
+	switch {
+	case stmt.Tok == token.CONTINUE && len(cont) > 0:
+		return gotoStmt(cont)
+	case stmt.Tok == token.BREAK && len(brk) > 0:
+		return gotoStmt(brk)
+	}
+	return stmt
+}
+
+// rewriteBranches rewrites the branch statements within the given statement,
+// which is not lowered, that target lowered loops. The label of the continue
+// target of unlabelled continue statements is specified by cont, if lowered.
+func (l *lowerer) rewriteBranches(stmt ast.Stmt, cont string) {
+	var rewrite func(stmts []ast.Stmt, cont string) []ast.Stmt
+	rewrite = func(stmts []ast.Stmt, cont string) []ast.Stmt {
+		for i, stmt := range stmts {
+			s := stmt
+			for {
+				labeled, ok := s.(*ast.LabeledStmt)
+				if !ok {
+					break
+				}
+				s = labeled.Stmt
+			}
+			if branch, ok := s.(*ast.BranchStmt); ok {
+				lowered := l.lowerBranch(branch, cont, "")
+				if labeled, ok := stmt.(*ast.LabeledStmt); ok {
+					labeled.Stmt = lowered
+				} else {
+					stmts[i] = lowered
+				}
+				continue
+			}
+			innerCont := cont
+			switch s.(type) {
+			case *ast.ForStmt, *ast.RangeStmt:
+				// Unlabelled continue statements within nested loops refer to
+				// the nested loop.
+				innerCont = ""
+			}
+			forEachList(s, func(stmts []ast.Stmt) []ast.Stmt {
+				return rewrite(stmts, innerCont)
+			})
+		}
+		return stmts
+	}
+	forEachList(stmt, func(stmts []ast.Stmt) []ast.Stmt {
+		return rewrite(stmts, cont)
+	})
+}
+
+// ### [ Labels ] ##############################################################
+
+// attachLabels attaches the labels of empty statements to their succeeding
+// statements of the given list.
+func attachLabels(stmts []ast.Stmt) []ast.Stmt {
+	var list []ast.Stmt
+	for i := len(stmts) - 1; i >= 0; i-- {
+		stmt := stmts[i]
+		if labeled, ok := stmt.(*ast.LabeledStmt); ok && len(list) > 0 {
+			if _, ok := labeled.Stmt.(*ast.EmptyStmt); ok {
+				labeled.Stmt = list[0]
+				list[0] = labeled
+				continue
+			}
+		}
+		list = append([]ast.Stmt{stmt}, list...)
+	}
+	return list
+}
+
+// pruneLabels removes the labels of the given function body which are not
+// targeted by any branch statement, as unused labels are illegal in Go.
+func pruneLabels(body *ast.BlockStmt) {
+	used := make(map[string]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if branch, ok := n.(*ast.BranchStmt); ok && branch.Label != nil {
+			used[branch.Label.Name] = true
+		}
+		return true
+	})
+	// strip removes the unused labels of the given statement.
+	var strip func(stmt ast.Stmt) ast.Stmt
+	strip = func(stmt ast.Stmt) ast.Stmt {
+		labeled, ok := stmt.(*ast.LabeledStmt)
+		if !ok {
+			return stmt
+		}
+		inner := strip(labeled.Stmt)
+		if !used[labeled.Label.Name] {
+			return inner
+		}
+		labeled.Stmt = inner
+		return labeled
+	}
+	var prune func(stmts []ast.Stmt) []ast.Stmt
+	prune = func(stmts []ast.Stmt) []ast.Stmt {
+		var list []ast.Stmt
+		for _, stmt := range stmts {
+			stmt = strip(stmt)
+			if _, ok := stmt.(*ast.EmptyStmt); ok {
+				// Empty statement of pruned label.
+				continue
+			}
+			forEachList(stmt, prune)
+			list = append(list, stmt)
+		}
+		return list
+	}
+	body.List = prune(body.List)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// gotoStmt returns a goto-statement to the given label.
+func gotoStmt(label string) *ast.BranchStmt {
+	return &ast.BranchStmt{
+		Tok:   token.GOTO,
+		Label: ast.NewIdent(label),
+	}
+}
+
+// labelStmt returns an empty statement with the given label.
+func labelStmt(label string) *ast.LabeledStmt {
+	return &ast.LabeledStmt{
+		Label: ast.NewIdent(label),
+		Stmt:  &ast.EmptyStmt{Implicit: true},
+	}
+}
+
+// condGoto returns a conditional goto-statement to the given label.
+func condGoto(cond ast.Expr, label string) *ast.IfStmt {
+	return &ast.IfStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{gotoStmt(label)},
+		},
+	}
+}
+
+// not returns the negation of the given boolean expression.
+func not(cond ast.Expr) ast.Expr {
+	return &ast.UnaryExpr{
+		Op: token.NOT,
+		X:  paren(cond),
+	}
+}
+
+// sortedKeys returns the sorted keys of the given set.
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
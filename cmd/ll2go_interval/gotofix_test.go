@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestFixGotos(t *testing.T) {
+	golden := []struct {
+		src string
+	}{
+		// Jump into if-statement.
+		{
+			src: `package p
+
+func f(c bool) int {
+	var x int
+	if c {
+		goto L
+	}
+	if x > 0 {
+		x = 2
+	L:
+		x++
+	} else {
+		x = 3
+	}
+	return x
+}`,
+		},
+		// Jump over variable declaration.
+		{
+			src: `package p
+
+func f() int {
+	goto L
+	var y int = 3
+L:
+	for i := 0; i < 3; i++ {
+		y++
+	}
+	return y
+}`,
+		},
+		// Jump into loop with break and continue statements.
+		{
+			src: `package p
+
+func f(c bool) int {
+	x := 0
+	goto M
+	for x < 10 {
+		if c {
+			continue
+		}
+		x++
+	M:
+		x += 2
+		switch x {
+		case 3:
+			break
+		case 4:
+			continue
+		}
+		if x == 7 {
+			break
+		}
+	}
+	return x
+}`,
+		},
+		// Jump into loop with break statements of labelled switch-statement.
+		{
+			src: `package p
+
+func f(c bool) int {
+	x := 0
+	goto M
+	for x < 10 {
+		x++
+	M:
+		x += 2
+	S:
+		switch x {
+		case 3:
+			for c {
+				break S
+			}
+		case 4:
+			if c {
+				break S
+			}
+			x++
+		}
+	}
+	return x
+}`,
+		},
+		// Jump into loop with several labels.
+		{
+			src: `package p
+
+func f(c bool) int {
+	x := 0
+	goto M
+L:
+K:
+	for x < 10 {
+		x++
+	M:
+		x += 2
+		if c {
+			break L
+		}
+		continue K
+	}
+	return x
+}`,
+		},
+	}
+	for i, gold := range golden {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", gold.src, 0)
+		if err != nil {
+			t.Errorf("i=%d: unable to parse source; %v", i, err)
+			continue
+		}
+		fn := file.Decls[0].(*ast.FuncDecl)
+		if err := fixGotos(fn); err != nil {
+			t.Errorf("i=%d: unable to repair goto-statements; %v", i, err)
+			continue
+		}
+		// Type-check the repaired source code.
+		buf := &bytes.Buffer{}
+		if err := printer.Fprint(buf, token.NewFileSet(), file); err != nil {
+			t.Errorf("i=%d: unable to print source; %v", i, err)
+			continue
+		}
+		fset = token.NewFileSet()
+		file, err = parser.ParseFile(fset, "", buf.Bytes(), 0)
+		if err != nil {
+			t.Errorf("i=%d: unable to parse repaired source; %v\n%s", i, err, buf)
+			continue
+		}
+		conf := types.Config{Importer: importer.Default()}
+		if _, err := conf.Check("p", fset, []*ast.File{file}, nil); err != nil {
+			t.Errorf("i=%d: invalid repaired source; %v\n%s", i, err, buf)
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
@@ -17,18 +20,23 @@ import (
 func (d *decompiler) insts(insts []ir.Instruction) []ast.Stmt {
 	var stmts []ast.Stmt
 	for _, inst := range insts {
-		switch inst := inst.(type) {
-		case *ir.InstPhi:
+		if _, ok := inst.(*ir.InstPhi); ok {
 			// PHI instructions are handled during the pre-processing of basic
 			// blocks.
 			continue
-		case *ir.InstSelect:
-			// A select instruction corresponds to more than one Go statement, thus
-			// it is handled outside of d.inst.
-			stmts = append(stmts, d.instSelect(inst)...)
+		}
+		stmt := d.inst(inst)
+		if block, ok := stmt.(*ast.BlockStmt); ok {
+			// Copy-and-update of aggregate values (e.g. insertvalue), spanning
+			// multiple statements.
+			for _, s := range block.List {
+				d.origins[s] = inst
+				stmts = append(stmts, s)
+			}
 			continue
 		}
-		stmts = append(stmts, d.inst(inst))
+		d.origins[stmt] = inst
+		stmts = append(stmts, stmt)
 	}
 	return stmts
 }
@@ -131,8 +139,7 @@ func (d *decompiler) inst(inst ir.Instruction) ast.Stmt {
 		// PHI instructions are handled by d.funcDecl.
 		panic(fmt.Sprintf("unexpected phi instruction `%v`", inst))
 	case *ir.InstSelect:
-		// select instructions are handled by d.insts.
-		panic(fmt.Sprintf("unexpected select instruction `%v`", inst))
+		return d.instSelect(inst)
 	case *ir.InstCall:
 		return d.instCall(inst)
 	default:
@@ -186,7 +193,7 @@ func (d *decompiler) instFMul(inst *ir.InstFMul) ast.Stmt {
 // instUDiv converts the given LLVM IR udiv instruction to a corresponding Go
 // statement.
 func (d *decompiler) instUDiv(inst *ir.InstUDiv) ast.Stmt {
-	expr := d.binaryOp(inst.X, token.QUO, inst.Y)
+	expr := d.unsignedBinaryOp(inst.X, token.QUO, inst.Y)
 	return d.assign(inst.LocalName, expr)
 }
 
@@ -207,7 +214,7 @@ func (d *decompiler) instFDiv(inst *ir.InstFDiv) ast.Stmt {
 // instURem converts the given LLVM IR urem instruction to a corresponding Go
 // statement.
 func (d *decompiler) instURem(inst *ir.InstURem) ast.Stmt {
-	expr := d.binaryOp(inst.X, token.REM, inst.Y)
+	expr := d.unsignedBinaryOp(inst.X, token.REM, inst.Y)
 	return d.assign(inst.LocalName, expr)
 }
 
@@ -228,23 +235,22 @@ func (d *decompiler) instFRem(inst *ir.InstFRem) ast.Stmt {
 // instShl converts the given LLVM IR shl instruction to a corresponding Go
 // statement.
 func (d *decompiler) instShl(inst *ir.InstShl) ast.Stmt {
-	expr := d.binaryOp(inst.X, token.SHL, inst.Y)
+	expr := d.shiftOp(inst.X, token.SHL, inst.Y)
 	return d.assign(inst.LocalName, expr)
 }
 
 // instLShr converts the given LLVM IR lshr instruction to a corresponding Go
 // statement.
 func (d *decompiler) instLShr(inst *ir.InstLShr) ast.Stmt {
-	expr := d.binaryOp(inst.X, token.SHR, inst.Y)
+	expr := d.unsignedBinaryOp(inst.X, token.SHR, inst.Y)
 	return d.assign(inst.LocalName, expr)
 }
 
 // instAShr converts the given LLVM IR ashr instruction to a corresponding Go
 // statement.
 func (d *decompiler) instAShr(inst *ir.InstAShr) ast.Stmt {
-	// TODO: Differentiate between logical shift right and arithmetic shift
-	// right.
-	expr := d.binaryOp(inst.X, token.SHR, inst.Y)
+	// Go performs arithmetic shift right on signed integers.
+	expr := d.shiftOp(inst.X, token.SHR, inst.Y)
 	return d.assign(inst.LocalName, expr)
 }
 
@@ -280,37 +286,259 @@ func (d *decompiler) instExtractElement(inst *ir.InstExtractElement) ast.Stmt {
 }
 
 // instInsertElement converts the given LLVM IR insertelement instruction to a
-// corresponding Go statement.
+// corresponding Go statement; a copy of the vector, followed by an update of
+// the element.
+//
+//    z = x
+//    z[index] = elem
 func (d *decompiler) instInsertElement(inst *ir.InstInsertElement) ast.Stmt {
-	// TODO: Implement insertelement.
-	panic("not yet implemented")
+	copyStmt := d.assign(inst.LocalName, d.value(inst.X))
+	dst := &ast.IndexExpr{
+		X:     d.localIdent(inst.LocalName),
+		Index: d.value(inst.Index),
+	}
+	updateStmt := &ast.AssignStmt{
+		Lhs: []ast.Expr{dst},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{d.value(inst.Elem)},
+	}
+	return &ast.BlockStmt{
+		List: []ast.Stmt{copyStmt, updateStmt},
+	}
 }
 
 // instShuffleVector converts the given LLVM IR shufflevector instruction to a
-// corresponding Go statement.
+// corresponding Go statement; a composite literal of the elements selected by
+// the mask.
+//
+//    z = [4]T{x[0], y[0], x[1], y[1]}
 func (d *decompiler) instShuffleVector(inst *ir.InstShuffleVector) ast.Stmt {
-	// TODO: Implement shufflevector.
-	panic("not yet implemented")
+	t, ok := inst.X.Type().(*irtypes.VectorType)
+	if !ok {
+		panic(fmt.Sprintf("invalid shufflevector operand type; expected *types.VectorType, got %T", inst.X.Type()))
+	}
+	mask := shuffleMask(inst.Mask)
+	var elems []ast.Expr
+	for _, index := range mask {
+		// Undefined mask elements (-1) select the first element of x.
+		src, i := inst.X, index
+		switch {
+		case index < 0:
+			i = 0
+		case uint64(index) >= t.Len:
+			// Indices [len, 2*len) select elements of y.
+			src, i = inst.Y, index-int64(t.Len)
+		}
+		elem := &ast.IndexExpr{
+			X:     d.value(src),
+			Index: d.intLit(i),
+		}
+		elems = append(elems, elem)
+	}
+	typ := &irtypes.VectorType{
+		Len:      uint64(len(mask)),
+		ElemType: t.ElemType,
+	}
+	expr := &ast.CompositeLit{
+		Type: d.goType(typ),
+		Elts: elems,
+	}
+	return d.assign(inst.LocalName, expr)
+}
+
+// shuffleMask returns the indices of the given shufflevector mask; where -1
+// represents an undefined mask element.
+func shuffleMask(mask value.Value) []int64 {
+	switch mask := mask.(type) {
+	case *constant.Vector:
+		var indices []int64
+		for _, elem := range mask.Elems {
+			switch elem := elem.(type) {
+			case *constant.Int:
+				indices = append(indices, elem.X.Int64())
+			case *constant.Undef:
+				indices = append(indices, -1)
+			default:
+				panic(fmt.Sprintf("support for shufflevector mask element %T not yet implemented", elem))
+			}
+		}
+		return indices
+	case *constant.ZeroInitializer:
+		return make([]int64, maskLen(mask.Typ))
+	case *constant.Undef:
+		indices := make([]int64, maskLen(mask.Typ))
+		for i := range indices {
+			indices[i] = -1
+		}
+		return indices
+	default:
+		panic(fmt.Sprintf("support for shufflevector mask %T not yet implemented", mask))
+	}
+}
+
+// maskLen returns the length of the given shufflevector mask type.
+func maskLen(t irtypes.Type) uint64 {
+	vt, ok := t.(*irtypes.VectorType)
+	if !ok {
+		panic(fmt.Sprintf("invalid shufflevector mask type; expected *types.VectorType, got %T", t))
+	}
+	return vt.Len
 }
 
 // instExtractValue converts the given LLVM IR extractvalue instruction to a
 // corresponding Go statement.
 func (d *decompiler) instExtractValue(inst *ir.InstExtractValue) ast.Stmt {
-	src := d.value(inst.X)
-	for _, index := range inst.Indices {
-		src = &ast.IndexExpr{
-			X:     src,
-			Index: d.uintLit(index),
-		}
-	}
+	src := d.aggregateElem(d.value(inst.X), inst.X.Type(), inst.Indices)
 	return d.assign(inst.LocalName, src)
 }
 
 // instInsertValue converts the given LLVM IR insertvalue instruction to a
-// corresponding Go statement.
+// corresponding Go statement; a copy of the aggregate value, followed by an
+// update of the element.
+//
+//    z = x
+//    z.field_1[2] = elem
 func (d *decompiler) instInsertValue(inst *ir.InstInsertValue) ast.Stmt {
-	// TODO: Implement insertvalue.
-	panic("not yet implemented")
+	copyStmt := d.assign(inst.LocalName, d.value(inst.X))
+	dst := d.aggregateElem(d.localIdent(inst.LocalName), inst.X.Type(), inst.Indices)
+	updateStmt := &ast.AssignStmt{
+		Lhs: []ast.Expr{dst},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{d.value(inst.Elem)},
+	}
+	return &ast.BlockStmt{
+		List: []ast.Stmt{copyStmt, updateStmt},
+	}
+}
+
+// gep returns a Go expression of the address computed by the given LLVM IR
+// getelementptr operation, with source element type elemType.
+//
+// The first index is an offset of src in units of the source element type,
+// which is computed using pointer arithmetic of the unsafe package unless zero.
+// The remaining indices are lowered by walking the source element type; i.e.
+// field selectors of structures and index expressions of arrays and vectors.
+//
+//    gep {i32, [4 x i32]}, {i32, [4 x i32]}* %p, i64 0, i32 1, i64 %i
+//
+//    &p.field_1[i]
+func (d *decompiler) gep(elemType irtypes.Type, src value.Value, indices []value.Value) ast.Expr {
+	if len(indices) == 0 {
+		return d.value(src)
+	}
+	x := d.value(src)
+	if !isZero(indices[0]) {
+		//    (*T)(unsafe.Pointer(uintptr(unsafe.Pointer(p)) + uintptr(i)*unsafe.Sizeof(*p)))
+		addr := &ast.CallExpr{
+			Fun:  ast.NewIdent("uintptr"),
+			Args: []ast.Expr{d.unsafePointer(x)},
+		}
+		// Negative constant offsets are subtracted, as constant conversions to
+		// uintptr must not overflow; non-constant offsets wrap around.
+		//
+		//    uintptr(unsafe.Pointer(p)) - uintptr(1)*unsafe.Sizeof(*p)
+		op := token.ADD
+		index := d.value(indices[0])
+		if lit, ok := index.(*ast.BasicLit); ok && strings.HasPrefix(lit.Value, "-") {
+			op = token.SUB
+			index = &ast.BasicLit{Kind: token.INT, Value: lit.Value[1:]}
+		}
+		offset := &ast.BinaryExpr{
+			X: &ast.CallExpr{
+				Fun:  ast.NewIdent("uintptr"),
+				Args: []ast.Expr{index},
+			},
+			Op: token.MUL,
+			Y: &ast.CallExpr{
+				Fun:  d.pkgSelector("unsafe", "Sizeof"),
+				Args: []ast.Expr{&ast.StarExpr{X: x}},
+			},
+		}
+		sum := &ast.BinaryExpr{
+			X:  addr,
+			Op: op,
+			Y:  offset,
+		}
+		x = &ast.CallExpr{
+			Fun:  &ast.ParenExpr{X: &ast.StarExpr{X: d.goType(elemType)}},
+			Args: []ast.Expr{d.unsafePointer(sum)},
+		}
+	}
+	if len(indices) == 1 {
+		return x
+	}
+	// Note, Go implicitly dereferences pointers to structures and arrays of
+	// selector and index expressions.
+	t := elemType
+	for _, index := range indices[1:] {
+		switch tt := t.(type) {
+		case *irtypes.StructType:
+			c, ok := index.(*constant.Int)
+			if !ok {
+				panic(fmt.Sprintf("invalid structure index type; expected *constant.Int, got %T", index))
+			}
+			x = &ast.SelectorExpr{
+				X:   x,
+				Sel: d.localIdent(fmt.Sprintf("field_%d", c.X.Int64())),
+			}
+			t = tt.Fields[c.X.Int64()]
+		case *irtypes.ArrayType:
+			x = &ast.IndexExpr{
+				X:     x,
+				Index: d.value(index),
+			}
+			t = tt.ElemType
+		case *irtypes.VectorType:
+			x = &ast.IndexExpr{
+				X:     x,
+				Index: d.value(index),
+			}
+			t = tt.ElemType
+		default:
+			panic(fmt.Sprintf("invalid getelementptr element type; expected *types.StructType, *types.ArrayType or *types.VectorType, got %T", t))
+		}
+	}
+	return &ast.UnaryExpr{
+		Op: token.AND,
+		X:  x,
+	}
+}
+
+// isZero reports whether the given LLVM IR value is the integer constant zero.
+func isZero(v value.Value) bool {
+	switch v := v.(type) {
+	case *constant.Int:
+		return v.X.Sign() == 0
+	case *constant.ZeroInitializer:
+		return true
+	default:
+		return false
+	}
+}
+
+// aggregateElem returns a Go expression of the element of the given aggregate
+// value x of type t, as specified by the extractvalue and insertvalue indices;
+// i.e. field selectors of structures and index expressions of arrays.
+func (d *decompiler) aggregateElem(x ast.Expr, t irtypes.Type, indices []uint64) ast.Expr {
+	for _, index := range indices {
+		switch tt := t.(type) {
+		case *irtypes.StructType:
+			x = &ast.SelectorExpr{
+				X:   x,
+				Sel: d.localIdent(fmt.Sprintf("field_%d", index)),
+			}
+			t = tt.Fields[index]
+		case *irtypes.ArrayType:
+			x = &ast.IndexExpr{
+				X:     x,
+				Index: d.uintLit(index),
+			}
+			t = tt.ElemType
+		default:
+			panic(fmt.Sprintf("invalid aggregate type; expected *types.StructType or *types.ArrayType, got %T", t))
+		}
+	}
+	return x
 }
 
 // instAlloca converts the given LLVM IR alloca instruction to a corresponding
@@ -356,18 +584,7 @@ func (d *decompiler) instStore(inst *ir.InstStore) ast.Stmt {
 // instGetElementPtr converts the given LLVM IR getelementptr instruction to a
 // corresponding Go statement.
 func (d *decompiler) instGetElementPtr(inst *ir.InstGetElementPtr) ast.Stmt {
-	src := d.value(inst.Src)
-	// TODO: Validate if index expressions should be added in reverse order.
-	for _, index := range inst.Indices {
-		src = &ast.IndexExpr{
-			X:     src,
-			Index: d.value(index),
-		}
-	}
-	expr := &ast.UnaryExpr{
-		Op: token.AND,
-		X:  src,
-	}
+	expr := d.gep(inst.ElemType, inst.Src, inst.Indices)
 	return d.assign(inst.LocalName, expr)
 }
 
@@ -381,7 +598,7 @@ func (d *decompiler) instTrunc(inst *ir.InstTrunc) ast.Stmt {
 // instZExt converts the given LLVM IR zext instruction to a corresponding Go
 // statement.
 func (d *decompiler) instZExt(inst *ir.InstZExt) ast.Stmt {
-	expr := d.convert(inst.From, inst.To)
+	expr := d.convertUnsigned(inst.From, inst.To)
 	return d.assign(inst.LocalName, expr)
 }
 
@@ -409,7 +626,7 @@ func (d *decompiler) instFPExt(inst *ir.InstFPExt) ast.Stmt {
 // instFPToUI converts the given LLVM IR fptoui instruction to a corresponding
 // Go statement.
 func (d *decompiler) instFPToUI(inst *ir.InstFPToUI) ast.Stmt {
-	expr := d.convert(inst.From, inst.To)
+	expr := d.convertToUnsigned(inst.From, inst.To)
 	return d.assign(inst.LocalName, expr)
 }
 
@@ -423,7 +640,7 @@ func (d *decompiler) instFPToSI(inst *ir.InstFPToSI) ast.Stmt {
 // instUIToFP converts the given LLVM IR uitofp instruction to a corresponding
 // Go statement.
 func (d *decompiler) instUIToFP(inst *ir.InstUIToFP) ast.Stmt {
-	expr := d.convert(inst.From, inst.To)
+	expr := d.convertUnsigned(inst.From, inst.To)
 	return d.assign(inst.LocalName, expr)
 }
 
@@ -437,36 +654,36 @@ func (d *decompiler) instSIToFP(inst *ir.InstSIToFP) ast.Stmt {
 // instPtrToInt converts the given LLVM IR ptrtoint instruction to a
 // corresponding Go statement.
 func (d *decompiler) instPtrToInt(inst *ir.InstPtrToInt) ast.Stmt {
-	expr := d.convert(inst.From, inst.To)
+	expr := d.ptrToInt(inst.From, inst.To)
 	return d.assign(inst.LocalName, expr)
 }
 
 // instIntToPtr converts the given LLVM IR inttoptr instruction to a
 // corresponding Go statement.
 func (d *decompiler) instIntToPtr(inst *ir.InstIntToPtr) ast.Stmt {
-	expr := d.convert(inst.From, inst.To)
+	expr := d.intToPtr(inst.From, inst.To)
 	return d.assign(inst.LocalName, expr)
 }
 
 // instBitCast converts the given LLVM IR bitcast instruction to a corresponding
 // Go statement.
 func (d *decompiler) instBitCast(inst *ir.InstBitCast) ast.Stmt {
-	expr := d.convert(inst.From, inst.To)
+	expr := d.bitCast(inst.From, inst.To)
 	return d.assign(inst.LocalName, expr)
 }
 
 // instAddrSpaceCast converts the given LLVM IR addrspacecast instruction to a
 // corresponding Go statement.
 func (d *decompiler) instAddrSpaceCast(inst *ir.InstAddrSpaceCast) ast.Stmt {
-	expr := d.convert(inst.From, inst.To)
+	// Address spaces are not represented in Go.
+	expr := d.bitCast(inst.From, inst.To)
 	return d.assign(inst.LocalName, expr)
 }
 
 // instICmp converts the given LLVM IR icmp instruction to a corresponding Go
 // statement.
 func (d *decompiler) instICmp(inst *ir.InstICmp) ast.Stmt {
-	op := ipred(inst.Pred)
-	expr := d.binaryOp(inst.X, op, inst.Y)
+	expr := d.icmp(inst.Pred, inst.X, inst.Y)
 	return d.assign(inst.LocalName, expr)
 }
 
@@ -480,17 +697,10 @@ func (d *decompiler) instFCmp(inst *ir.InstFCmp) ast.Stmt {
 
 // instSelect converts the given LLVM IR select instruction to a corresponding
 // Go statement.
-func (d *decompiler) instSelect(inst *ir.InstSelect) []ast.Stmt {
-	spec := &ast.ValueSpec{
-		Names: []*ast.Ident{d.localIdent(inst.LocalName)},
-		Type:  d.goType(inst.X.Type()),
-	}
-	declStmt := &ast.DeclStmt{
-		Decl: &ast.GenDecl{
-			Tok:   token.VAR,
-			Specs: []ast.Spec{spec},
-		},
-	}
+//
+// Note, the local variable of the select instruction is declared by
+// d.localDecl.
+func (d *decompiler) instSelect(inst *ir.InstSelect) ast.Stmt {
 	ifStmt := &ast.IfStmt{
 		Cond: d.value(inst.Cond),
 		Body: &ast.BlockStmt{
@@ -500,7 +710,7 @@ func (d *decompiler) instSelect(inst *ir.InstSelect) []ast.Stmt {
 			List: []ast.Stmt{d.assign(inst.LocalName, d.value(inst.Y))},
 		},
 	}
-	return []ast.Stmt{declStmt, ifStmt}
+	return ifStmt
 }
 
 // instCall converts the given LLVM IR call instruction to a corresponding Go
@@ -511,15 +721,13 @@ func (d *decompiler) instCall(inst *ir.InstCall) ast.Stmt {
 	case *ir.Func:
 		// global function identifier.
 		callee = d.globalIdent(c.GlobalName)
-	case *ir.Param:
-		// local function identifier.
-		callee = d.localIdent(c.LocalName)
-	case *constant.ExprBitCast:
-		callee = d.value(c)
-	case *ir.InstBitCast:
-		callee = d.value(c)
-	case *ir.InstLoad:
-		callee = d.value(c)
+	case *ir.Param, *constant.ExprBitCast, *ir.InstBitCast, *ir.InstLoad:
+		// Function pointers are of pointer to function type (e.g. `*func()`).
+		//
+		//    (*p)(args)
+		callee = &ast.ParenExpr{
+			X: &ast.StarExpr{X: d.value(c)},
+		}
 	default:
 		panic(fmt.Sprintf("support for callee type %T not yet implemented", c))
 	}
@@ -540,21 +748,265 @@ func (d *decompiler) instCall(inst *ir.InstCall) ast.Stmt {
 // binaryOp converts the given LLVM IR binary operation to a corresponding Go
 // expression.
 func (d *decompiler) binaryOp(x value.Value, op token.Token, y value.Value) ast.Expr {
-	return &ast.BinaryExpr{
+	if isBool(x.Type()) {
+		return d.boolOp(x, op, y)
+	}
+	if t, ok := x.Type().(*irtypes.IntType); ok && isWide(t) {
+		return d.wideBinaryOp(t, d.value(x), op, d.value(y))
+	}
+	expr := &ast.BinaryExpr{
 		X:  d.value(x),
 		Op: op,
 		Y:  d.value(y),
 	}
+	switch op {
+	case token.ADD, token.SUB, token.MUL, token.QUO:
+		// The result of arithmetic operations may overflow the bit size of
+		// non-builtin integer types.
+		return d.wrap(x.Type(), expr)
+	}
+	return expr
+}
+
+// unsignedBinaryOp converts the given LLVM IR binary operation, which treats
+// its integer operands as unsigned, to a corresponding Go expression; e.g.
+// `int32(uint32(x) / uint32(y))`.
+func (d *decompiler) unsignedBinaryOp(x value.Value, op token.Token, y value.Value) ast.Expr {
+	t, ok := x.Type().(*irtypes.IntType)
+	if !ok {
+		// TODO: Add support for unsigned operations on vectors of integers.
+		return d.binaryOp(x, op, y)
+	}
+	if isBool(t) {
+		return d.boolOp(x, op, y)
+	}
+	if isWide(t) {
+		// e.g. wrapInt(new(big.Int).Quo(uwrapInt(x, 128), uwrapInt(y, 128)), 128)
+		ux, uy := d.unsigned(x), d.unsigned(y)
+		switch op {
+		case token.QUO:
+			return wrapInt(t, d.bigOp("Quo", ux, uy))
+		case token.REM:
+			return wrapInt(t, d.bigOp("Rem", ux, uy))
+		case token.SHR:
+			return wrapInt(t, d.bigOp("Rsh", ux, shiftCount(uy)))
+		default:
+			panic(fmt.Sprintf("support for unsigned wide integer operator %v not yet implemented", op))
+		}
+	}
+	expr := &ast.BinaryExpr{
+		X:  d.unsigned(x),
+		Op: op,
+		Y:  d.unsigned(y),
+	}
+	conv := &ast.CallExpr{
+		Fun:  d.goType(t),
+		Args: []ast.Expr{expr},
+	}
+	// Unsigned results of non-builtin integer types are sign-extended.
+	return d.wrap(t, conv)
+}
+
+// shiftOp converts the given LLVM IR shift operation to a corresponding Go
+// expression. The shift count is converted to an unsigned integer, as required
+// by Go.
+func (d *decompiler) shiftOp(x value.Value, op token.Token, y value.Value) ast.Expr {
+	if isBool(x.Type()) {
+		return d.boolOp(x, op, y)
+	}
+	if t, ok := x.Type().(*irtypes.IntType); ok && isWide(t) {
+		return d.wideBinaryOp(t, d.value(x), op, d.value(y))
+	}
+	expr := &ast.BinaryExpr{
+		X:  d.value(x),
+		Op: op,
+		Y:  d.unsigned(y),
+	}
+	if op == token.SHL {
+		return d.wrap(x.Type(), expr)
+	}
+	return expr
+}
+
+// icmp converts the given LLVM IR integer comparison to a corresponding Go
+// expression. The operands of unsigned predicates are converted to unsigned
+// integers.
+func (d *decompiler) icmp(pred enum.IPred, x, y value.Value) ast.Expr {
+	if isBool(x.Type()) {
+		return d.boolCmp(pred, x, y)
+	}
+	op := ipred(pred)
+	if !unsignedPred(pred) {
+		return d.binaryOp(x, op, y)
+	}
+	if t, ok := x.Type().(*irtypes.IntType); ok && isWide(t) {
+		return d.wideBinaryOp(t, d.unsigned(x), op, d.unsigned(y))
+	}
+	return &ast.BinaryExpr{
+		X:  d.unsigned(x),
+		Op: op,
+		Y:  d.unsigned(y),
+	}
+}
+
+// unsigned converts the given LLVM IR value to a corresponding Go expression,
+// interpreting integers as unsigned; e.g. `uint32(x)`, `(uint24(x) & 0xFFFFFF)`
+// or `uwrapInt(x, 128)`. Values of non-integer type are left as is.
+func (d *decompiler) unsigned(v value.Value) ast.Expr {
+	t, ok := v.Type().(*irtypes.IntType)
+	if !ok {
+		return d.value(v)
+	}
+	if isWide(t) {
+		return uwrapInt(t, d.value(v))
+	}
+	if c, ok := v.(*constant.Int); ok {
+		// Negative constants overflow unsigned integer types in Go; use the
+		// two's complement representation instead.
+		m := new(big.Int).Lsh(big.NewInt(1), uint(t.BitSize))
+		u := new(big.Int).Mod(c.X, m)
+		return &ast.CallExpr{
+			Fun: d.uintType(t),
+			Args: []ast.Expr{
+				&ast.BasicLit{Kind: token.INT, Value: u.String()},
+			},
+		}
+	}
+	expr := &ast.CallExpr{
+		Fun:  d.uintType(t),
+		Args: []ast.Expr{d.value(v)},
+	}
+	if t.BitSize == backingSize(t.BitSize) {
+		return expr
+	}
+	// Mask out the sign-extended bits of non-builtin integer types.
+	mask := &ast.BasicLit{
+		Kind:  token.INT,
+		Value: fmt.Sprintf("0x%X", uint64(1)<<t.BitSize-1),
+	}
+	return &ast.ParenExpr{
+		X: &ast.BinaryExpr{
+			X:  expr,
+			Op: token.AND,
+			Y:  mask,
+		},
+	}
+}
+
+// wrap sign-extends the result of the given Go expression of non-builtin
+// integer type from its bit size to the bit size of its underlying Go type;
+// e.g. `(x + y) << 8 >> 8` for int24, which is backed by int32. Expressions of
+// other types are left as is.
+//
+// Note, values of non-builtin integer types are always kept sign-extended, so
+// that their underlying Go type holds the same value as the LLVM IR integer.
+func (d *decompiler) wrap(t irtypes.Type, expr ast.Expr) ast.Expr {
+	it, ok := t.(*irtypes.IntType)
+	if !ok || isWide(it) || it.BitSize == backingSize(it.BitSize) {
+		return expr
+	}
+	shift := &ast.BasicLit{
+		Kind:  token.INT,
+		Value: strconv.FormatUint(backingSize(it.BitSize)-it.BitSize, 10),
+	}
+	if _, ok := expr.(*ast.BinaryExpr); ok {
+		expr = &ast.ParenExpr{X: expr}
+	}
+	shl := &ast.BinaryExpr{
+		X:  expr,
+		Op: token.SHL,
+		Y:  shift,
+	}
+	return &ast.BinaryExpr{
+		X:  shl,
+		Op: token.SHR,
+		Y:  shift,
+	}
 }
 
 // convert returns a Go statement for converting the given LLVM IR value into
 // the specified type.
 func (d *decompiler) convert(from value.Value, to irtypes.Type) ast.Expr {
+	if isBool(from.Type()) {
+		return d.fromBool(from, to, false)
+	}
+	if isBool(to) {
+		return d.toBool(from, false)
+	}
+	fromType, fromInt := from.Type().(*irtypes.IntType)
+	toType, toInt := to.(*irtypes.IntType)
+	if fromInt && toInt && (isWide(fromType) || isWide(toType)) {
+		return d.wideConvert(d.value(from), fromType, toType, false)
+	}
+	if (fromInt && isWide(fromType)) || (toInt && isWide(toType)) {
+		panic(fmt.Sprintf("support for conversion from %v to %v not yet implemented", from.Type(), to))
+	}
 	// Type conversion represented as a Go call expression.
+	expr := &ast.CallExpr{
+		Fun:  d.goType(to),
+		Args: []ast.Expr{d.value(from)},
+	}
+	if fromInt && toInt && fromType.BitSize <= toType.BitSize {
+		// Sign-extended values fit the destination type.
+		return expr
+	}
+	return d.wrap(to, expr)
+}
+
+// convertUnsigned returns a Go expression for converting the given LLVM IR
+// value into the specified type, interpreting integers as unsigned; e.g.
+// `int64(uint32(x))`.
+func (d *decompiler) convertUnsigned(from value.Value, to irtypes.Type) ast.Expr {
+	if isBool(from.Type()) {
+		return d.fromBool(from, to, true)
+	}
+	fromType, fromInt := from.Type().(*irtypes.IntType)
+	toType, toInt := to.(*irtypes.IntType)
+	if fromInt && toInt && (isWide(fromType) || isWide(toType)) {
+		x := d.value(from)
+		if !isWide(fromType) {
+			x = d.unsigned(from)
+		}
+		return d.wideConvert(x, fromType, toType, true)
+	}
+	if (fromInt && isWide(fromType)) || (toInt && isWide(toType)) {
+		panic(fmt.Sprintf("support for conversion from %v to %v not yet implemented", from.Type(), to))
+	}
+	x := d.unsigned(from)
+	if paren, ok := x.(*ast.ParenExpr); ok {
+		// e.g. `int32(uint24(x) & 0xFFFFFF)`
+		x = paren.X
+	}
 	return &ast.CallExpr{
 		Fun:  d.goType(to),
+		Args: []ast.Expr{x},
+	}
+}
+
+// convertToUnsigned returns a Go expression for converting the given LLVM IR
+// value into the specified type, interpreting the result as unsigned; e.g.
+// `int32(uint32(x))`.
+func (d *decompiler) convertToUnsigned(from value.Value, to irtypes.Type) ast.Expr {
+	t, ok := to.(*irtypes.IntType)
+	if !ok {
+		// TODO: Add support for unsigned conversions of vectors.
+		return d.convert(from, to)
+	}
+	if isBool(t) {
+		return d.toBool(from, true)
+	}
+	if isWide(t) {
+		panic(fmt.Sprintf("support for conversion from %v to %v not yet implemented", from.Type(), to))
+	}
+	expr := &ast.CallExpr{
+		Fun:  d.uintType(t),
 		Args: []ast.Expr{d.value(from)},
 	}
+	conv := &ast.CallExpr{
+		Fun:  d.goType(to),
+		Args: []ast.Expr{expr},
+	}
+	return d.wrap(t, conv)
 }
 
 // assign returns an assignment statement, assigning expr to the given local
@@ -569,8 +1021,10 @@ func (d *decompiler) assign(name string, expr ast.Expr) *ast.AssignStmt {
 
 // ipred converts the given LLVM IR integer predicate to a corresponding Go
 // token.
+//
+// Note, the operands of unsigned predicates must be converted to unsigned
+// integers by the caller; see unsignedPred.
 func ipred(pred enum.IPred) token.Token {
-	// TODO: Differentiate between unsigned and signed.
 	switch pred {
 	case enum.IPredEQ:
 		return token.EQL
@@ -597,6 +1051,17 @@ func ipred(pred enum.IPred) token.Token {
 	}
 }
 
+// unsignedPred reports whether the given LLVM IR integer predicate compares its
+// operands as unsigned integers.
+func unsignedPred(pred enum.IPred) bool {
+	switch pred {
+	case enum.IPredUGT, enum.IPredUGE, enum.IPredULT, enum.IPredULE:
+		return true
+	default:
+		return false
+	}
+}
+
 // fpred converts the given LLVM IR floating-point predicate to a corresponding
 // Go token.
 func fpred(pred enum.FPred) token.Token {
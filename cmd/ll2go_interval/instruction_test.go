@@ -0,0 +1,782 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	irtypes "github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+func TestUnsigned(t *testing.T) {
+	var (
+		i24 = &irtypes.IntType{BitSize: 24}
+		x   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "x"}, Typ: irtypes.I32}
+		y   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "y"}, Typ: irtypes.I32}
+		a   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "a"}, Typ: i24}
+		f   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "f"}, Typ: irtypes.Double}
+		neg = &constant.Int{Typ: irtypes.I32, X: big.NewInt(-1)}
+		two = &constant.Int{Typ: irtypes.I32, X: big.NewInt(2)}
+		z   = ir.LocalIdent{LocalName: "z"}
+	)
+	golden := []struct {
+		inst ir.Instruction
+		want string
+	}{
+		// udiv
+		{
+			inst: &ir.InstUDiv{LocalIdent: z, X: x, Y: y, Typ: irtypes.I32},
+			want: "z = int32(uint32(x) / uint32(y))",
+		},
+		// udiv with negative constant operand.
+		{
+			inst: &ir.InstUDiv{LocalIdent: z, X: neg, Y: y, Typ: irtypes.I32},
+			want: "z = int32(uint32(4294967295) / uint32(y))",
+		},
+		// urem
+		{
+			inst: &ir.InstURem{LocalIdent: z, X: x, Y: y, Typ: irtypes.I32},
+			want: "z = int32(uint32(x) % uint32(y))",
+		},
+		// urem of non-builtin integer type.
+		{
+			inst: &ir.InstURem{LocalIdent: z, X: a, Y: a, Typ: i24},
+			want: "z = int24((uint24(a)&0xFFFFFF)%(uint24(a)&0xFFFFFF)) << 8 >> 8",
+		},
+		// lshr
+		{
+			inst: &ir.InstLShr{LocalIdent: z, X: x, Y: y, Typ: irtypes.I32},
+			want: "z = int32(uint32(x) >> uint32(y))",
+		},
+		// ashr
+		{
+			inst: &ir.InstAShr{LocalIdent: z, X: x, Y: two, Typ: irtypes.I32},
+			want: "z = x >> uint32(2)",
+		},
+		// shl
+		{
+			inst: &ir.InstShl{LocalIdent: z, X: x, Y: y, Typ: irtypes.I32},
+			want: "z = x << uint32(y)",
+		},
+		// zext
+		{
+			inst: &ir.InstZExt{LocalIdent: z, From: x, To: irtypes.I64},
+			want: "z = int64(uint32(x))",
+		},
+		// uitofp
+		{
+			inst: &ir.InstUIToFP{LocalIdent: z, From: x, To: irtypes.Double},
+			want: "z = float64(uint32(x))",
+		},
+		// fptoui
+		{
+			inst: &ir.InstFPToUI{LocalIdent: z, From: f, To: irtypes.I32},
+			want: "z = int32(uint32(f))",
+		},
+		// icmp ugt
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredUGT, X: x, Y: y},
+			want: "z = uint32(x) > uint32(y)",
+		},
+		// icmp uge
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredUGE, X: x, Y: y},
+			want: "z = uint32(x) >= uint32(y)",
+		},
+		// icmp ult
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredULT, X: x, Y: neg},
+			want: "z = uint32(x) < uint32(4294967295)",
+		},
+		// icmp ule
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredULE, X: x, Y: y},
+			want: "z = uint32(x) <= uint32(y)",
+		},
+		// icmp slt
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredSLT, X: x, Y: neg},
+			want: "z = x < -1",
+		},
+	}
+	for i, gold := range golden {
+		d := newDecompiler()
+		got := printNode(t, d.inst(gold.inst))
+		if got != gold.want {
+			t.Errorf("i=%d: output mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+	}
+}
+
+func TestUnsignedExpr(t *testing.T) {
+	var (
+		neg = &constant.Int{Typ: irtypes.I32, X: big.NewInt(-8)}
+		two = &constant.Int{Typ: irtypes.I32, X: big.NewInt(2)}
+	)
+	golden := []struct {
+		expr constant.Expression
+		want string
+	}{
+		// udiv
+		{
+			expr: &constant.ExprUDiv{X: neg, Y: two, Typ: irtypes.I32},
+			want: "int32(uint32(4294967288) / uint32(2))",
+		},
+		// urem
+		{
+			expr: &constant.ExprURem{X: neg, Y: two, Typ: irtypes.I32},
+			want: "int32(uint32(4294967288) % uint32(2))",
+		},
+		// lshr
+		{
+			expr: &constant.ExprLShr{X: neg, Y: two, Typ: irtypes.I32},
+			want: "int32(uint32(4294967288) >> uint32(2))",
+		},
+		// ashr
+		{
+			expr: &constant.ExprAShr{X: neg, Y: two, Typ: irtypes.I32},
+			want: "-8 >> uint32(2)",
+		},
+		// shl
+		{
+			expr: &constant.ExprShl{X: neg, Y: two, Typ: irtypes.I32},
+			want: "-8 << uint32(2)",
+		},
+		// zext
+		{
+			expr: &constant.ExprZExt{From: neg, To: irtypes.I64},
+			want: "int64(uint32(4294967288))",
+		},
+		// uitofp
+		{
+			expr: &constant.ExprUIToFP{From: neg, To: irtypes.Double},
+			want: "float64(uint32(4294967288))",
+		},
+		// fptoui
+		{
+			expr: &constant.ExprFPToUI{From: &constant.Float{Typ: irtypes.Double, X: big.NewFloat(2)}, To: irtypes.I32},
+			want: "int32(uint32(2))",
+		},
+		// icmp ugt
+		{
+			expr: &constant.ExprICmp{Pred: enum.IPredUGT, X: neg, Y: two},
+			want: "uint32(4294967288) > uint32(2)",
+		},
+		// icmp uge
+		{
+			expr: &constant.ExprICmp{Pred: enum.IPredUGE, X: neg, Y: two},
+			want: "uint32(4294967288) >= uint32(2)",
+		},
+		// icmp ult
+		{
+			expr: &constant.ExprICmp{Pred: enum.IPredULT, X: neg, Y: two},
+			want: "uint32(4294967288) < uint32(2)",
+		},
+		// icmp ule
+		{
+			expr: &constant.ExprICmp{Pred: enum.IPredULE, X: neg, Y: two},
+			want: "uint32(4294967288) <= uint32(2)",
+		},
+	}
+	for i, gold := range golden {
+		d := newDecompiler()
+		got := printNode(t, d.expr(gold.expr))
+		if got != gold.want {
+			t.Errorf("i=%d: output mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+	}
+}
+
+func TestOddWidth(t *testing.T) {
+	var (
+		i24 = &irtypes.IntType{BitSize: 24}
+		a   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "a"}, Typ: i24}
+		b   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "b"}, Typ: i24}
+		x   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "x"}, Typ: irtypes.I32}
+		z   = ir.LocalIdent{LocalName: "z"}
+	)
+	golden := []struct {
+		inst ir.Instruction
+		want string
+	}{
+		// add
+		{
+			inst: &ir.InstAdd{LocalIdent: z, X: a, Y: b, Typ: i24},
+			want: "z = (a + b) << 8 >> 8",
+		},
+		// mul with constant exceeding the signed range.
+		{
+			inst: &ir.InstMul{LocalIdent: z, X: a, Y: &constant.Int{Typ: i24, X: big.NewInt(0xFFFFFF)}, Typ: i24},
+			want: "z = (a * -1) << 8 >> 8",
+		},
+		// and
+		{
+			inst: &ir.InstAnd{LocalIdent: z, X: a, Y: b, Typ: i24},
+			want: "z = a & b",
+		},
+		// shl
+		{
+			inst: &ir.InstShl{LocalIdent: z, X: a, Y: b, Typ: i24},
+			want: "z = (a << (uint24(b) & 0xFFFFFF)) << 8 >> 8",
+		},
+		// lshr
+		{
+			inst: &ir.InstLShr{LocalIdent: z, X: a, Y: b, Typ: i24},
+			want: "z = int24((uint24(a)&0xFFFFFF)>>(uint24(b)&0xFFFFFF)) << 8 >> 8",
+		},
+		// icmp ult
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredULT, X: a, Y: b},
+			want: "z = (uint24(a) & 0xFFFFFF) < (uint24(b) & 0xFFFFFF)",
+		},
+		// trunc
+		{
+			inst: &ir.InstTrunc{LocalIdent: z, From: x, To: i24},
+			want: "z = int24(x) << 8 >> 8",
+		},
+		// sext
+		{
+			inst: &ir.InstSExt{LocalIdent: z, From: a, To: irtypes.I32},
+			want: "z = int32(a)",
+		},
+		// zext
+		{
+			inst: &ir.InstZExt{LocalIdent: z, From: a, To: irtypes.I32},
+			want: "z = int32(uint24(a) & 0xFFFFFF)",
+		},
+	}
+	for i, gold := range golden {
+		d := newDecompiler()
+		got := printNode(t, d.inst(gold.inst))
+		if got != gold.want {
+			t.Errorf("i=%d: output mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+	}
+}
+
+func TestBool(t *testing.T) {
+	var (
+		i1  = &irtypes.IntType{BitSize: 1}
+		i24 = &irtypes.IntType{BitSize: 24}
+		p   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "p"}, Typ: i1}
+		q   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "q"}, Typ: i1}
+		x   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "x"}, Typ: irtypes.I32}
+		f   = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "f"}, Typ: irtypes.Double}
+		tru = &constant.Int{Typ: i1, X: big.NewInt(1)}
+		z   = ir.LocalIdent{LocalName: "z"}
+	)
+	golden := []struct {
+		inst ir.Instruction
+		want string
+	}{
+		// and
+		{
+			inst: &ir.InstAnd{LocalIdent: z, X: p, Y: q, Typ: i1},
+			want: "z = p && q",
+		},
+		// or
+		{
+			inst: &ir.InstOr{LocalIdent: z, X: p, Y: q, Typ: i1},
+			want: "z = p || q",
+		},
+		// xor with constant true.
+		{
+			inst: &ir.InstXor{LocalIdent: z, X: p, Y: tru, Typ: i1},
+			want: "z = p != true",
+		},
+		// add
+		{
+			inst: &ir.InstAdd{LocalIdent: z, X: p, Y: q, Typ: i1},
+			want: "z = p != q",
+		},
+		// lshr
+		{
+			inst: &ir.InstLShr{LocalIdent: z, X: p, Y: q, Typ: i1},
+			want: "z = p",
+		},
+		// icmp eq
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredEQ, X: p, Y: q},
+			want: "z = p == q",
+		},
+		// icmp ult
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredULT, X: p, Y: q},
+			want: "z = !p && q",
+		},
+		// icmp slt; true is -1 when signed.
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredSLT, X: p, Y: q},
+			want: "z = p && !q",
+		},
+		// icmp sle
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredSLE, X: p, Y: q},
+			want: "z = p || !q",
+		},
+		// trunc
+		{
+			inst: &ir.InstTrunc{LocalIdent: z, From: x, To: i1},
+			want: "z = x&1 != 0",
+		},
+		// fptoui
+		{
+			inst: &ir.InstFPToUI{LocalIdent: z, From: f, To: i1},
+			want: "z = f >= 1",
+		},
+		// fptosi
+		{
+			inst: &ir.InstFPToSI{LocalIdent: z, From: f, To: i1},
+			want: "z = f <= -1",
+		},
+		// zext
+		{
+			inst: &ir.InstZExt{LocalIdent: z, From: p, To: irtypes.I32},
+			want: "z = func() int32 {\n\tif p {\n\t\treturn 1\n\t}\n\treturn 0\n}()",
+		},
+		// sext to non-builtin integer type.
+		{
+			inst: &ir.InstSExt{LocalIdent: z, From: p, To: i24},
+			want: "z = func() int24 {\n\tif p {\n\t\treturn -1\n\t}\n\treturn 0\n}()",
+		},
+		// sitofp
+		{
+			inst: &ir.InstSIToFP{LocalIdent: z, From: p, To: irtypes.Double},
+			want: "z = func() float64 {\n\tif p {\n\t\treturn -1\n\t}\n\treturn 0\n}()",
+		},
+	}
+	for i, gold := range golden {
+		d := newDecompiler()
+		got := printNode(t, d.inst(gold.inst))
+		if got != gold.want {
+			t.Errorf("i=%d: output mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+	}
+	// i1 is declared as bool.
+	d := newDecompiler()
+	if got, want := printNode(t, d.goType(i1)), "bool"; got != want {
+		t.Errorf("type mismatch; expected `%s`, got `%s`", want, got)
+	}
+	if d.intSizes[1] {
+		t.Errorf("unexpected declaration of int1")
+	}
+}
+
+func TestWideInt(t *testing.T) {
+	var (
+		i128 = &irtypes.IntType{BitSize: 128}
+		a    = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "a"}, Typ: i128}
+		b    = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "b"}, Typ: i128}
+		x    = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "x"}, Typ: irtypes.I64}
+		max  = new(big.Int).Lsh(big.NewInt(1), 127)
+		z    = ir.LocalIdent{LocalName: "z"}
+	)
+	golden := []struct {
+		inst ir.Instruction
+		want string
+	}{
+		// add
+		{
+			inst: &ir.InstAdd{LocalIdent: z, X: a, Y: b, Typ: i128},
+			want: "z = wrapInt(new(big.Int).Add(a, b), 128)",
+		},
+		// sub with small constant.
+		{
+			inst: &ir.InstSub{LocalIdent: z, X: a, Y: &constant.Int{Typ: i128, X: big.NewInt(1)}, Typ: i128},
+			want: "z = wrapInt(new(big.Int).Sub(a, big.NewInt(1)), 128)",
+		},
+		// xor with large constant.
+		{
+			inst: &ir.InstXor{LocalIdent: z, X: a, Y: &constant.Int{Typ: i128, X: max}, Typ: i128},
+			want: `z = new(big.Int).Xor(a, bigInt("-170141183460469231731687303715884105728"))`,
+		},
+		// ashr
+		{
+			inst: &ir.InstAShr{LocalIdent: z, X: a, Y: b, Typ: i128},
+			want: "z = new(big.Int).Rsh(a, uint(b.Uint64()))",
+		},
+		// udiv
+		{
+			inst: &ir.InstUDiv{LocalIdent: z, X: a, Y: b, Typ: i128},
+			want: "z = wrapInt(new(big.Int).Quo(uwrapInt(a, 128), uwrapInt(b, 128)), 128)",
+		},
+		// lshr
+		{
+			inst: &ir.InstLShr{LocalIdent: z, X: a, Y: b, Typ: i128},
+			want: "z = wrapInt(new(big.Int).Rsh(uwrapInt(a, 128), uint(uwrapInt(b, 128).Uint64())), 128)",
+		},
+		// icmp slt
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredSLT, X: a, Y: b},
+			want: "z = a.Cmp(b) < 0",
+		},
+		// icmp ugt
+		{
+			inst: &ir.InstICmp{LocalIdent: z, Pred: enum.IPredUGT, X: a, Y: b},
+			want: "z = uwrapInt(a, 128).Cmp(uwrapInt(b, 128)) > 0",
+		},
+		// sext
+		{
+			inst: &ir.InstSExt{LocalIdent: z, From: x, To: i128},
+			want: "z = big.NewInt(int64(x))",
+		},
+		// zext
+		{
+			inst: &ir.InstZExt{LocalIdent: z, From: x, To: i128},
+			want: "z = new(big.Int).SetUint64(uint64(uint64(x)))",
+		},
+		// trunc
+		{
+			inst: &ir.InstTrunc{LocalIdent: z, From: a, To: irtypes.I64},
+			want: "z = int64(wrapInt(a, 64).Int64())",
+		},
+	}
+	for i, gold := range golden {
+		d := newDecompiler()
+		got := printNode(t, d.inst(gold.inst))
+		if got != gold.want {
+			t.Errorf("i=%d: output mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	var (
+		v4i32   = &irtypes.VectorType{Len: 4, ElemType: irtypes.I32}
+		pair    = &irtypes.StructType{Fields: []irtypes.Type{irtypes.I64, irtypes.I64}}
+		nested  = &irtypes.StructType{Fields: []irtypes.Type{irtypes.I32, &irtypes.ArrayType{Len: 3, ElemType: irtypes.Double}}}
+		a       = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "a"}, Typ: v4i32}
+		b       = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "b"}, Typ: v4i32}
+		x       = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "x"}, Typ: irtypes.I32}
+		y       = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "y"}, Typ: irtypes.I64}
+		s       = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "s"}, Typ: nested}
+		f       = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "f"}, Typ: irtypes.Double}
+		ins0    = &ir.InstInsertValue{LocalIdent: ir.LocalIdent{LocalName: "agg0"}, X: &constant.Undef{Typ: pair}, Elem: y, Indices: []uint64{0}, Typ: pair}
+		i32     = func(x int64) constant.Constant { return &constant.Int{Typ: irtypes.I32, X: big.NewInt(x)} }
+		shuffle = func(mask ...constant.Constant) *constant.Vector {
+			return &constant.Vector{Typ: &irtypes.VectorType{Len: uint64(len(mask)), ElemType: irtypes.I32}, Elems: mask}
+		}
+	)
+	golden := []struct {
+		inst ir.Instruction
+		want string
+	}{
+		// v4si f(v4si a, int x) { a[2] = x; return a; }
+		//
+		//    %vecins = insertelement <4 x i32> %a, i32 %x, i64 2
+		{
+			inst: &ir.InstInsertElement{LocalIdent: ir.LocalIdent{LocalName: "vecins"}, X: a, Elem: x, Index: &constant.Int{Typ: irtypes.I64, X: big.NewInt(2)}, Typ: v4i32},
+			want: "vecins = a\nvecins[2] = x",
+		},
+		// v4si f(v4si a, int x, int i) { a[i] = x; return a; }
+		//
+		//    %vecins = insertelement <4 x i32> %a, i32 %x, i32 %i
+		{
+			inst: &ir.InstInsertElement{LocalIdent: ir.LocalIdent{LocalName: "vecins"}, X: a, Elem: x, Index: &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "i"}, Typ: irtypes.I32}, Typ: v4i32},
+			want: "vecins = a\nvecins[i] = x",
+		},
+		// v4si f(v4si a, v4si b) { return __builtin_shufflevector(a, b, 0, 4, 1, 5); }
+		//
+		//    %shuffle = shufflevector <4 x i32> %a, <4 x i32> %b, <4 x i32> <i32 0, i32 4, i32 1, i32 5>
+		{
+			inst: &ir.InstShuffleVector{LocalIdent: ir.LocalIdent{LocalName: "shuffle"}, X: a, Y: b, Mask: shuffle(i32(0), i32(4), i32(1), i32(5)), Typ: v4i32},
+			want: "shuffle = [4]int32{a[0], b[0], a[1], b[1]}",
+		},
+		// v2si f(v4si a) { return __builtin_shufflevector(a, a, 3, -1); }
+		//
+		//    %shuffle = shufflevector <4 x i32> %a, <4 x i32> undef, <2 x i32> <i32 3, i32 undef>
+		{
+			inst: &ir.InstShuffleVector{LocalIdent: ir.LocalIdent{LocalName: "shuffle"}, X: a, Y: &constant.Undef{Typ: v4i32}, Mask: shuffle(i32(3), &constant.Undef{Typ: irtypes.I32}), Typ: &irtypes.VectorType{Len: 2, ElemType: irtypes.I32}},
+			want: "shuffle = [2]int32{a[3], a[0]}",
+		},
+		// v4si f(int x) { return (v4si){x, x, x, x}; }
+		//
+		//    %splat = shufflevector <4 x i32> %splatinsert, <4 x i32> undef, <4 x i32> zeroinitializer
+		{
+			inst: &ir.InstShuffleVector{LocalIdent: ir.LocalIdent{LocalName: "splat"}, X: a, Y: &constant.Undef{Typ: v4i32}, Mask: &constant.ZeroInitializer{Typ: v4i32}, Typ: v4i32},
+			want: "splat = [4]int32{a[0], a[0], a[0], a[0]}",
+		},
+		// struct pair { long a, b; };
+		// struct pair f(long a, long b) { return (struct pair){a, b}; }
+		//
+		//    %agg0 = insertvalue { i64, i64 } undef, i64 %a, 0
+		{
+			inst: ins0,
+			want: "agg0 = *new(struct {\n\tfield_0\tint64\n\tfield_1\tint64\n})\nagg0.field_0 = y",
+		},
+		//    %agg1 = insertvalue { i64, i64 } %agg0, i64 %b, 1
+		{
+			inst: &ir.InstInsertValue{LocalIdent: ir.LocalIdent{LocalName: "agg1"}, X: ins0, Elem: y, Indices: []uint64{1}, Typ: pair},
+			want: "agg1 = agg0\nagg1.field_1 = y",
+		},
+		// struct s { int a; double b[3]; };
+		// double f(struct s s) { return s.b[2]; }
+		//
+		//    %b2 = extractvalue { i32, [3 x double] } %s, 1, 2
+		{
+			inst: &ir.InstExtractValue{LocalIdent: ir.LocalIdent{LocalName: "b2"}, X: s, Indices: []uint64{1, 2}, Typ: irtypes.Double},
+			want: "b2 = s.field_1[2]",
+		},
+		// struct s f(struct s s, double d) { s.b[1] = d; return s; }
+		//
+		//    %b1 = insertvalue { i32, [3 x double] } %s, double %d, 1, 1
+		{
+			inst: &ir.InstInsertValue{LocalIdent: ir.LocalIdent{LocalName: "b1"}, X: s, Elem: f, Indices: []uint64{1, 1}, Typ: nested},
+			want: "b1 = s\nb1.field_1[1] = f",
+		},
+	}
+	for i, gold := range golden {
+		d := newDecompiler()
+		var stmts []string
+		for _, stmt := range d.insts([]ir.Instruction{gold.inst}) {
+			stmts = append(stmts, printNode(t, stmt))
+		}
+		got := strings.Join(stmts, "\n")
+		if got != gold.want {
+			t.Errorf("i=%d: output mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+	}
+}
+
+func TestGetElementPtr(t *testing.T) {
+	var (
+		str    = &irtypes.ArrayType{Len: 6, ElemType: irtypes.I8}
+		arr    = &irtypes.ArrayType{Len: 4, ElemType: irtypes.I32}
+		st     = &irtypes.StructType{Fields: []irtypes.Type{irtypes.I32, arr}}
+		s      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "s"}, Typ: &irtypes.PointerType{ElemType: str}}
+		p      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "p"}, Typ: &irtypes.PointerType{ElemType: st}}
+		q      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "q"}, Typ: &irtypes.PointerType{ElemType: irtypes.I32}}
+		a      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "a"}, Typ: &irtypes.PointerType{ElemType: arr}}
+		i      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "i"}, Typ: irtypes.I64}
+		i32    = func(x int64) *constant.Int { return &constant.Int{Typ: irtypes.I32, X: big.NewInt(x)} }
+		i64    = func(x int64) *constant.Int { return &constant.Int{Typ: irtypes.I64, X: big.NewInt(x)} }
+		z      = ir.LocalIdent{LocalName: "z"}
+		offset = "uintptr(unsafe.Pointer(%s)) %s uintptr(%s)*unsafe.Sizeof(*%s)"
+	)
+	golden := []struct {
+		inst    ir.Instruction
+		want    string
+		imports []string
+	}{
+		// %z = getelementptr [6 x i8], [6 x i8]* %s, i64 0, i64 0
+		{
+			inst: &ir.InstGetElementPtr{LocalIdent: z, ElemType: str, Src: s, Indices: []value.Value{i64(0), i64(0)}},
+			want: "z = &s[0]",
+		},
+		// %z = getelementptr {i32, [4 x i32]}, {i32, [4 x i32]}* %p, i64 0, i32 1
+		{
+			inst: &ir.InstGetElementPtr{LocalIdent: z, ElemType: st, Src: p, Indices: []value.Value{i64(0), i32(1)}},
+			want: "z = &p.field_1",
+		},
+		// %z = getelementptr {i32, [4 x i32]}, {i32, [4 x i32]}* %p, i64 0, i32 1, i64 %i
+		{
+			inst: &ir.InstGetElementPtr{LocalIdent: z, ElemType: st, Src: p, Indices: []value.Value{i64(0), i32(1), i}},
+			want: "z = &p.field_1[i]",
+		},
+		// %z = getelementptr i32, i32* %q, i64 0
+		{
+			inst: &ir.InstGetElementPtr{LocalIdent: z, ElemType: irtypes.I32, Src: q, Indices: []value.Value{i64(0)}},
+			want: "z = q",
+		},
+		// %z = getelementptr i32, i32* %q, i64 %i
+		{
+			inst:    &ir.InstGetElementPtr{LocalIdent: z, ElemType: irtypes.I32, Src: q, Indices: []value.Value{i}},
+			want:    "z = (*int32)(unsafe.Pointer(" + fmt.Sprintf(offset, "q", "+", "i", "q") + "))",
+			imports: []string{"unsafe"},
+		},
+		// %z = getelementptr [4 x i32], [4 x i32]* %a, i64 -1, i64 3
+		{
+			inst:    &ir.InstGetElementPtr{LocalIdent: z, ElemType: arr, Src: a, Indices: []value.Value{i64(-1), i64(3)}},
+			want:    "z = &(*[4]int32)(unsafe.Pointer(" + fmt.Sprintf(offset, "a", "-", "1", "a") + "))[3]",
+			imports: []string{"unsafe"},
+		},
+		// %z = getelementptr i32, i32* %q, i64 2
+		{
+			inst:    &ir.InstGetElementPtr{LocalIdent: z, ElemType: irtypes.I32, Src: q, Indices: []value.Value{i64(2)}},
+			want:    "z = (*int32)(unsafe.Pointer(" + fmt.Sprintf(offset, "q", "+", "2", "q") + "))",
+			imports: []string{"unsafe"},
+		},
+		// %z = getelementptr i32, i32* %q, i32 -2
+		{
+			inst:    &ir.InstGetElementPtr{LocalIdent: z, ElemType: irtypes.I32, Src: q, Indices: []value.Value{i32(-2)}},
+			want:    "z = (*int32)(unsafe.Pointer(" + fmt.Sprintf(offset, "q", "-", "2", "q") + "))",
+			imports: []string{"unsafe"},
+		},
+	}
+	params := []*ir.Param{s, p, q, a, i}
+	for i, gold := range golden {
+		d := newDecompiler()
+		stmt := d.inst(gold.inst)
+		got := printNode(t, stmt)
+		if got != gold.want {
+			t.Errorf("i=%d: output mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+		if got := importPaths(d.imports); !reflect.DeepEqual(got, gold.imports) {
+			t.Errorf("i=%d: imports mismatch; expected %q, got %q", i, gold.imports, got)
+		}
+		zParam := &ir.Param{LocalIdent: z, Typ: gold.inst.(*ir.InstGetElementPtr).Type()}
+		if err := typeCheck(d, stmt, append(params, zParam)...); err != nil {
+			t.Errorf("i=%d: type error in `%s`; %v", i, got, err)
+		}
+	}
+}
+
+func TestCast(t *testing.T) {
+	var (
+		i32ptr = &irtypes.PointerType{ElemType: irtypes.I32}
+		i8ptr  = &irtypes.PointerType{ElemType: irtypes.I8}
+		p      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "p"}, Typ: i32ptr}
+		x      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "x"}, Typ: irtypes.I32}
+		y      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "y"}, Typ: irtypes.I64}
+		f      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "f"}, Typ: irtypes.Double}
+		v      = &ir.Param{LocalIdent: ir.LocalIdent{LocalName: "v"}, Typ: &irtypes.VectorType{Len: 2, ElemType: irtypes.I32}}
+		fn     = newFunc("fn", &irtypes.FuncType{RetType: irtypes.Void})
+		z      = ir.LocalIdent{LocalName: "z"}
+	)
+	golden := []struct {
+		inst    ir.Instruction
+		want    string
+		imports []string
+	}{
+		// %z = ptrtoint i32* %p to i64
+		{
+			inst:    &ir.InstPtrToInt{LocalIdent: z, From: p, To: irtypes.I64},
+			want:    "z = int64(uintptr(unsafe.Pointer(p)))",
+			imports: []string{"unsafe"},
+		},
+		// %z = inttoptr i32 %x to i32*
+		{
+			inst:    &ir.InstIntToPtr{LocalIdent: z, From: x, To: i32ptr},
+			want:    "z = (*int32)(unsafe.Pointer(uintptr(uint32(x))))",
+			imports: []string{"unsafe"},
+		},
+		// %z = bitcast i32* %p to i8*
+		{
+			inst:    &ir.InstBitCast{LocalIdent: z, From: p, To: i8ptr},
+			want:    "z = (*int8)(unsafe.Pointer(p))",
+			imports: []string{"unsafe"},
+		},
+		// %z = bitcast void ()* @fn to i8*
+		{
+			inst:    &ir.InstBitCast{LocalIdent: z, From: fn, To: i8ptr},
+			want:    "z = (*int8)(unsafe.Pointer(&fn_addr))",
+			imports: []string{"unsafe"},
+		},
+		// %z = bitcast i32* %p to void ()*
+		{
+			inst:    &ir.InstBitCast{LocalIdent: z, From: p, To: fn.Typ},
+			want:    "z = (*func())(unsafe.Pointer(p))",
+			imports: []string{"unsafe"},
+		},
+		// %z = addrspacecast i32* %p to i8*
+		{
+			inst:    &ir.InstAddrSpaceCast{LocalIdent: z, From: p, To: i8ptr},
+			want:    "z = (*int8)(unsafe.Pointer(p))",
+			imports: []string{"unsafe"},
+		},
+		// %z = bitcast double %f to i64
+		{
+			inst:    &ir.InstBitCast{LocalIdent: z, From: f, To: irtypes.I64},
+			want:    "z = int64(math.Float64bits(f))",
+			imports: []string{"math"},
+		},
+		// %z = bitcast i32 %x to float
+		{
+			inst:    &ir.InstBitCast{LocalIdent: z, From: x, To: irtypes.Float},
+			want:    "z = math.Float32frombits(uint32(x))",
+			imports: []string{"math"},
+		},
+		// %z = bitcast i64 %y to double
+		{
+			inst:    &ir.InstBitCast{LocalIdent: z, From: y, To: irtypes.Double},
+			want:    "z = math.Float64frombits(uint64(y))",
+			imports: []string{"math"},
+		},
+		// %z = bitcast <2 x i32> %v to i64
+		{
+			inst:    &ir.InstBitCast{LocalIdent: z, From: v, To: irtypes.I64},
+			want:    "z = *(*int64)(unsafe.Pointer(&v))",
+			imports: []string{"unsafe"},
+		},
+	}
+	for i, gold := range golden {
+		d := newDecompiler()
+		got := printNode(t, d.inst(gold.inst))
+		if got != gold.want {
+			t.Errorf("i=%d: output mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+		if got := importPaths(d.imports); !reflect.DeepEqual(got, gold.imports) {
+			t.Errorf("i=%d: imports mismatch; expected %q, got %q", i, gold.imports, got)
+		}
+	}
+}
+
+// printNode returns the Go source code of the given node.
+func printNode(t *testing.T, node ast.Node) string {
+	buf := &bytes.Buffer{}
+	if err := printer.Fprint(buf, token.NewFileSet(), node); err != nil {
+		t.Fatalf("unable to print node; %v", err)
+	}
+	return buf.String()
+}
+
+// typeCheck type-checks the given Go statement, as the body of a function with
+// the given parameters.
+func typeCheck(d *decompiler, stmt ast.Stmt, params ...*ir.Param) error {
+	fields := &ast.FieldList{}
+	for _, param := range params {
+		field := &ast.Field{
+			Names: []*ast.Ident{d.localIdent(param.LocalName)},
+			Type:  d.goType(param.Type()),
+		}
+		fields.List = append(fields.List, field)
+	}
+	file := &ast.File{
+		Name: ast.NewIdent("p"),
+	}
+	if len(d.imports) > 0 {
+		file.Decls = append(file.Decls, importDecl(d.imports))
+	}
+	fn := &ast.FuncDecl{
+		Name: ast.NewIdent("_"),
+		Type: &ast.FuncType{Params: fields},
+		Body: &ast.BlockStmt{List: []ast.Stmt{stmt}},
+	}
+	file.Decls = append(file.Decls, fn)
+	buf := &bytes.Buffer{}
+	if err := printer.Fprint(buf, token.NewFileSet(), file); err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, "out.go", buf.Bytes(), 0)
+	if err != nil {
+		return err
+	}
+	conf := types.Config{Importer: importer.Default()}
+	_, err = conf.Check(parsed.Name.Name, fset, []*ast.File{parsed}, nil)
+	return err
+}
+
+// importPaths returns the sorted import paths of the given standard packages.
+func importPaths(imports map[string]bool) []string {
+	var paths []string
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
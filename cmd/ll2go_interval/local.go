@@ -0,0 +1,112 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/llir/llvm/ir"
+	irtypes "github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// localDecl returns a variable declaration of the local variables of the given
+// LLVM IR function; i.e. the results of instructions, including PHI
+// instructions and allocas. Local variables never read by the given function
+// body are left out, and assignments to them are replaced by assignments to the
+// blank identifier. The declaration is nil if no local variable is read.
+//
+// Note, Go does not permit goto-statements to jump over variable declarations,
+// nor into blocks. Therefore, all local variables are declared at the top of
+// the function body, before any labelled statement.
+func (d *decompiler) localDecl(f *ir.Func, body *ast.BlockStmt) *ast.DeclStmt {
+	used := usedIdents(body)
+	var specs []ast.Spec
+	unused := make(map[string]bool)
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			if _, ok := inst.(*ir.InstStore); ok {
+				// store instructions produce no result.
+				continue
+			}
+			v, ok := inst.(value.Named)
+			if !ok || irtypes.Equal(v.Type(), irtypes.Void) {
+				continue
+			}
+			name := d.localIdent(v.Name()).Name
+			if !used[name] {
+				unused[name] = true
+				continue
+			}
+			spec := &ast.ValueSpec{
+				Names: []*ast.Ident{ast.NewIdent(name)},
+				Type:  d.goType(v.Type()),
+			}
+			specs = append(specs, spec)
+		}
+	}
+	blankUnused(body, unused)
+	if len(specs) == 0 {
+		return nil
+	}
+	return &ast.DeclStmt{
+		Decl: &ast.GenDecl{
+			Tok:   token.VAR,
+			Specs: specs,
+		},
+	}
+}
+
+// usedIdents returns the set of identifiers read by the given function body;
+// i.e. every identifier except those only assigned to.
+func usedIdents(body *ast.BlockStmt) map[string]bool {
+	used := make(map[string]bool)
+	var mark func(n ast.Node) bool
+	mark = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if _, ok := lhs.(*ast.Ident); !ok {
+					// e.g. `*x = y`, which reads x.
+					ast.Inspect(lhs, mark)
+				}
+			}
+			for _, rhs := range n.Rhs {
+				ast.Inspect(rhs, mark)
+			}
+			return false
+		case *ast.LabeledStmt:
+			ast.Inspect(n.Stmt, mark)
+			return false
+		case *ast.BranchStmt:
+			return false
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, mark)
+			return false
+		case *ast.Ident:
+			used[n.Name] = true
+		}
+		return true
+	}
+	ast.Inspect(body, mark)
+	return used
+}
+
+// blankUnused replaces assignments to the given unused local variables of the
+// function body by assignments to the blank identifier.
+func blankUnused(body *ast.BlockStmt, unused map[string]bool) {
+	if len(unused) == 0 {
+		return
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok {
+			return true
+		}
+		for _, lhs := range assign.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && unused[id.Name] {
+				id.Name = "_"
+			}
+		}
+		return true
+	})
+}
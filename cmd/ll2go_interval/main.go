@@ -9,14 +9,36 @@
 //
 // Flags:
 //
+//    -entry string
+//          label of the entry basic block (default: entry basic block of each function)
+//    -check
+//          type-check the generated Go source code and print a summary of each function
 //    -funcs string
 //          comma-separated list of functions to parse
+//    -j int
+//          number of functions to decompile concurrently (default 1)
+//    -maxdepth int
+//          maximum length of the derived sequence of graphs (0 = unlimited)
+//    -maxnodes int
+//          maximum number of nodes in a control flow graph (0 = unlimited)
 //    -q    suppress non-error messages
+//    -timeout duration
+//          maximum control flow analysis time per function (0 = unlimited)
+//
+// The resource limits of -maxdepth, -maxnodes and -timeout only apply to the
+// generation of control flow primitives, when no JSON file of primitives is
+// present (<src>_graphs/<func>.json). The same holds for -entry, the label of
+// which is used for each function; combine it with -funcs to specify the entry
+// basic block of a single function. Note, the primitives are not yet used to
+// structure the generated Go source code, which relies on goto-statements.
+//
+// The generated Go source code is printed even if -check reports type errors,
+// in which case the exit status is 1.
 package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	goerrors "errors"
 	"flag"
 	"fmt"
@@ -26,9 +48,12 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/graphism/exp/cfg"
@@ -42,6 +67,7 @@ import (
 	"github.com/mewkiz/pkg/term"
 	"github.com/mewmew/cfa/interval"
 	"github.com/mewmew/cfa/primitive"
+	"github.com/mewmew/cfa/reader"
 	"github.com/pkg/errors"
 	"gonum.org/v1/gonum/graph"
 )
@@ -67,16 +93,30 @@ Flags:
 func main() {
 	// Parse command line flags.
 	var (
+		// check specifies whether to type-check the generated Go source code.
+		check bool
+		// entry specifies the label of the entry basic block.
+		entry string
 		// funcs represents a comma-separated list of functions to parse.
 		funcs string
+		// jobs specifies the number of functions to decompile concurrently.
+		jobs int
 		// quiet specifies whether to suppress non-error messages.
 		quiet bool
+		// limits specifies the resource limits of control flow analysis.
+		limits interval.Limits
 	)
+	flag.BoolVar(&check, "check", false, "type-check the generated Go source code and print a summary of each function")
+	flag.StringVar(&entry, "entry", "", "label of the entry basic block (default: entry basic block of each function)")
 	flag.StringVar(&funcs, "funcs", "", "comma-separated list of functions to parse")
+	flag.IntVar(&jobs, "j", 1, "number of functions to decompile concurrently")
+	flag.IntVar(&limits.MaxDepth, "maxdepth", 0, "maximum length of the derived sequence of graphs (0 = unlimited)")
+	flag.IntVar(&limits.MaxNodes, "maxnodes", 0, "maximum number of nodes in a control flow graph (0 = unlimited)")
 	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.DurationVar(&limits.Timeout, "timeout", 0, "maximum control flow analysis time per function (0 = unlimited)")
 	flag.Usage = usage
 	flag.Parse()
-	if flag.NArg() == 0 {
+	if flag.NArg() == 0 || jobs < 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -94,9 +134,12 @@ func main() {
 	}
 
 	// Decompile LLVM IR files to Go source code.
+	failed := false
 	for _, llPath := range flag.Args() {
-		file, err := ll2go(llPath, funcNames)
-		if err != nil {
+		file, err := ll2go(llPath, funcNames, entry, limits, jobs, check)
+		if err == ErrTypeCheck {
+			failed = true
+		} else if err != nil {
 			log.Fatalf("%+v", err)
 		}
 		// TODO: Remove debug output.
@@ -105,11 +148,18 @@ func main() {
 		}
 		fmt.Println()
 	}
+	if failed {
+		os.Exit(1)
+	}
 }
 
 // ll2go converts the given LLVM IR assembly file into a corresponding Go source
-// file.
-func ll2go(llPath string, funcNames map[string]bool) (*ast.File, error) {
+// file, limiting the resources of control flow analysis to the given limits.
+// Functions are decompiled concurrently by the given number of jobs. If check
+// is set, the Go source file is type-checked and a summary of each function is
+// printed to standard error; the Go source file is returned together with
+// ErrTypeCheck if it contains type errors.
+func ll2go(llPath string, funcNames map[string]bool, entry string, limits interval.Limits, jobs int, check bool) (*ast.File, error) {
 	dbg.Printf("parsing file %q.", llPath)
 	module, err := asm.ParseFile(llPath)
 	if err != nil {
@@ -147,24 +197,33 @@ func ll2go(llPath string, funcNames map[string]bool) (*ast.File, error) {
 		if f.GlobalName == "main" {
 			hasMain = true
 		}
-		var prims *primitive.Primitives
-		if len(f.Blocks) > 0 {
-			// Determine the control flow primitives of the function.
-			//
-			//    1. Check if JSON file present on file system
-			//    2. If present, parse prims from file and log to dbg that
-			//       primitives are read from the JSON file.
-			//    3. If not present, perform control flow analysis in memory.
-			prims, err = parsePrims(srcName, f)
-			if err != nil {
-				return nil, errors.WithStack(err)
-			}
-		}
-		dbg.Printf("decompiling function %q.", f.Ident())
-		fn, err := d.funcDecl(f, prims)
-		if err != nil {
-			return nil, errors.WithStack(err)
+	}
+	fns, err := d.funcDecls(srcName, funcs, entry, limits, jobs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Add variables holding the function values of function addresses, as used
+	// by global initializers and functions.
+	if decl, inits := d.funcAddrDecl(); decl != nil {
+		file.Decls = append(file.Decls, decl)
+		d.inits = append(inits, d.inits...)
+	}
+	if len(d.inits) > 0 {
+		// Add init function assigning the global initializers which Go cannot
+		// express as part of variable declarations.
+		initFunc := &ast.FuncDecl{
+			Name: ast.NewIdent("init"),
+			Type: &ast.FuncType{
+				Params: &ast.FieldList{},
+			},
+			Body: &ast.BlockStmt{
+				List: d.inits,
+			},
 		}
+		file.Decls = append(file.Decls, initFunc)
+	}
+	for _, fn := range fns {
 		file.Decls = append(file.Decls, fn)
 	}
 
@@ -173,9 +232,12 @@ func ll2go(llPath string, funcNames map[string]bool) (*ast.File, error) {
 	for newIntSize := range d.newIntSizes {
 		newIntSizes = append(newIntSizes, newIntSize)
 	}
+	sort.Slice(newIntSizes, func(i, j int) bool {
+		return newIntSizes[i] < newIntSizes[j]
+	})
 	for _, newIntSize := range newIntSizes {
 		x := ast.NewIdent("x")
-		intType := ast.NewIdent(fmt.Sprintf("int%d", newIntSize))
+		intType := d.goType(&irtypes.IntType{BitSize: newIntSize})
 		param := &ast.Field{
 			Names: []*ast.Ident{x},
 			Type:  intType,
@@ -208,11 +270,65 @@ func ll2go(llPath string, funcNames map[string]bool) (*ast.File, error) {
 	}
 
 	// Add types not part of builtin.
+	file.Decls = append(file.Decls, intTypeDecls("int", d.intSizes)...)
+	file.Decls = append(file.Decls, intTypeDecls("uint", d.uintSizes)...)
+
+	// Add helper functions of integer types wider than 64 bits, which are
+	// represented by *big.Int.
+	if usesWideInts(d.intSizes) {
+		wideDecls, err := wideIntDecls()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		file.Decls = append(file.Decls, wideDecls...)
+		d.imports["math/big"] = true
+	}
+
+	// Add import declaration of standard packages used.
+	if len(d.imports) > 0 {
+		file.Decls = append([]ast.Decl{importDecl(d.imports)}, file.Decls...)
+	}
+
+	// Set package name.
+	if hasMain {
+		file.Name = ast.NewIdent("main")
+	} else {
+		file.Name = ident(srcName)
+	}
+
+	// Type-check generated Go source code if `-check` is set.
+	if check {
+		funcOf := make(map[*ast.FuncDecl]*ir.Func)
+		for i, fn := range fns {
+			funcOf[fn] = funcs[i]
+		}
+		ok, err := d.check(os.Stderr, srcName+".go", file, funcOf)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !ok {
+			return file, ErrTypeCheck
+		}
+	}
+
+	return file, nil
+}
+
+// intTypeDecls returns type declarations of the integer types of the given bit
+// sizes not part of Go builtin, with the given type name prefix ("int" or
+// "uint"). Each type is backed by the smallest builtin integer type of larger
+// bit size.
+//
+// Note, integer types wider than 64 bits are represented by *big.Int, and are
+// thus not declared.
+func intTypeDecls(prefix string, sizes map[uint64]bool) []ast.Decl {
 	var intSizes []uint64
-	for intSize := range d.intSizes {
-		switch intSize {
-		case 8, 16, 32, 64:
+	for intSize := range sizes {
+		switch {
+		case intSize == 8, intSize == 16, intSize == 32, intSize == 64:
 			// already builtin type of Go.
+		case intSize > 64:
+			// represented by *big.Int.
 		default:
 			intSizes = append(intSizes, intSize)
 		}
@@ -221,21 +337,10 @@ func ll2go(llPath string, funcNames map[string]bool) (*ast.File, error) {
 		return intSizes[i] < intSizes[j]
 	}
 	sort.Slice(intSizes, less)
+	var decls []ast.Decl
 	for _, intSize := range intSizes {
-		typeName := fmt.Sprintf("int%d", intSize)
-		var underlying string
-		switch {
-		case intSize < 8:
-			underlying = "int8"
-		case intSize < 16:
-			underlying = "int16"
-		case intSize < 32:
-			underlying = "int32"
-		case intSize < 64:
-			underlying = "int64"
-		default:
-			return nil, errors.Errorf("support for integer type with bit size %d not yet implemented", intSize)
-		}
+		typeName := fmt.Sprintf("%s%d", prefix, intSize)
+		underlying := fmt.Sprintf("%s%d", prefix, backingSize(intSize))
 		spec := &ast.TypeSpec{
 			Name: ast.NewIdent(typeName),
 			Type: ast.NewIdent(underlying),
@@ -244,17 +349,34 @@ func ll2go(llPath string, funcNames map[string]bool) (*ast.File, error) {
 			Tok:   token.TYPE,
 			Specs: []ast.Spec{spec},
 		}
-		file.Decls = append(file.Decls, typeDecl)
+		decls = append(decls, typeDecl)
 	}
+	return decls
+}
 
-	// Set package name.
-	if hasMain {
-		file.Name = ast.NewIdent("main")
-	} else {
-		file.Name = ident(srcName)
+// importDecl returns the import declaration of the given standard packages,
+// sorted by import path.
+func importDecl(imports map[string]bool) *ast.GenDecl {
+	var paths []string
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	decl := &ast.GenDecl{
+		Tok: token.IMPORT,
+	}
+	if len(paths) > 1 {
+		// Note, a valid left parenthesis position is required to print multiple
+		// import specifications.
+		decl.Lparen = 1
+	}
+	for _, path := range paths {
+		spec := &ast.ImportSpec{
+			Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)},
+		}
+		decl.Specs = append(decl.Specs, spec)
 	}
-
-	return file, nil
+	return decl
 }
 
 // A decompiler keeps track of relevant information during the decompilation
@@ -264,8 +386,20 @@ type decompiler struct {
 
 	// Tracks use of integer types not part of Go builtin.
 	intSizes map[uint64]bool
+	// Tracks use of unsigned integer types not part of Go builtin.
+	uintSizes map[uint64]bool
 	// Tracks use of newIntNNN function calls.
 	newIntSizes map[uint64]bool
+	// Tracks use of standard packages, by import path.
+	imports map[string]bool
+	// Tracks use of function addresses, by function name.
+	funcAddrs map[string]*ir.Func
+	// Map from Go statement to the LLVM IR instruction or terminator from which
+	// it was generated.
+	origins map[ast.Stmt]llStringer
+	// Assignments of global initializers, in order of global declaration; part
+	// of the init function of the Go source file.
+	inits []ast.Stmt
 
 	// Per function states.
 
@@ -279,7 +413,102 @@ type decompiler struct {
 func newDecompiler() *decompiler {
 	return &decompiler{
 		intSizes:    make(map[uint64]bool),
+		uintSizes:   make(map[uint64]bool),
 		newIntSizes: make(map[uint64]bool),
+		imports:     make(map[string]bool),
+		funcAddrs:   make(map[string]*ir.Func),
+		origins:     make(map[ast.Stmt]llStringer),
+	}
+}
+
+// funcDecls converts the given LLVM IR functions into corresponding Go function
+// declarations, using the given number of concurrent jobs. The function
+// declarations are returned in the same order as the LLVM IR functions,
+// regardless of the number of jobs.
+func (d *decompiler) funcDecls(srcName string, funcs []*ir.Func, entry string, limits interval.Limits, jobs int) ([]*ast.FuncDecl, error) {
+	fns := make([]*ast.FuncDecl, len(funcs))
+	errs := make([]error, len(funcs))
+	// Each worker uses a decompiler of its own, the global states of which are
+	// merged into d once all functions have been decompiled.
+	workers := make([]*decompiler, jobs)
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := range workers {
+		workers[i] = newDecompiler()
+		wg.Add(1)
+		go func(w *decompiler) {
+			defer wg.Done()
+			for i := range indices {
+				fns[i], errs[i] = w.decompileFunc(srcName, funcs[i], entry, limits)
+			}
+		}(workers[i])
+	}
+	for i := range funcs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	for _, w := range workers {
+		d.merge(w)
+	}
+	// Report the first error in function order, to keep the output
+	// deterministic.
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fns, nil
+}
+
+// decompileFunc determines the control flow primitives of the given LLVM IR
+// function and converts it into a corresponding Go function declaration.
+func (d *decompiler) decompileFunc(srcName string, f *ir.Func, entry string, limits interval.Limits) (*ast.FuncDecl, error) {
+	var prims *primitive.Primitives
+	if len(f.Blocks) > 0 {
+		// Determine the control flow primitives of the function.
+		//
+		//    1. Check if JSON file present on file system
+		//    2. If present, parse prims from file and log to dbg that
+		//       primitives are read from the JSON file.
+		//    3. If not present, perform control flow analysis in memory.
+		var err error
+		prims, err = parsePrims(srcName, f, entry, limits)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	dbg.Printf("decompiling function %q.", f.Ident())
+	fn, err := d.funcDecl(f, prims)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Repair goto-statements which are illegal in Go.
+	if err := fixGotos(fn); err != nil {
+		return nil, errors.Errorf("unable to repair goto-statements of function %q; %v", f.Ident(), err)
+	}
+	return fn, nil
+}
+
+// merge merges the global states of the decompiler w into d.
+func (d *decompiler) merge(w *decompiler) {
+	for intSize := range w.intSizes {
+		d.intSizes[intSize] = true
+	}
+	for uintSize := range w.uintSizes {
+		d.uintSizes[uintSize] = true
+	}
+	for newIntSize := range w.newIntSizes {
+		d.newIntSizes[newIntSize] = true
+	}
+	for path := range w.imports {
+		d.imports[path] = true
+	}
+	for name, f := range w.funcAddrs {
+		d.funcAddrs[name] = f
+	}
+	for stmt, origin := range w.origins {
+		d.origins[stmt] = origin
 	}
 }
 
@@ -302,7 +531,7 @@ func (d *decompiler) globalDecl(g *ir.Global) *ast.GenDecl {
 	spec := &ast.ValueSpec{
 		Names:  []*ast.Ident{d.globalIdent(g.GlobalName)},
 		Type:   d.goType(g.Typ),
-		Values: []ast.Expr{d.pointerToConst(g.Init)},
+		Values: []ast.Expr{d.pointerToConst(g)},
 	}
 	return &ast.GenDecl{
 		Tok:   token.VAR,
@@ -310,10 +539,13 @@ func (d *decompiler) globalDecl(g *ir.Global) *ast.GenDecl {
 	}
 }
 
-// pointerToConst converts the given LLVM IR constant to a pointer to c and
-// returns the corresponding Go expression.
-func (d *decompiler) pointerToConst(c constant.Constant) ast.Expr {
-	switch c := c.(type) {
+// pointerToConst converts the initializer of the given LLVM IR global to a
+// pointer to its content and returns the corresponding Go expression.
+//
+// Initializers which Go cannot express as part of the variable declaration are
+// assigned by the init function of the Go source file; see d.deferInit.
+func (d *decompiler) pointerToConst(g *ir.Global) ast.Expr {
+	switch c := g.Init.(type) {
 	// Simple constants
 	case *constant.Int:
 		callee := fmt.Sprintf("newInt%d", c.Typ.BitSize)
@@ -323,36 +555,149 @@ func (d *decompiler) pointerToConst(c constant.Constant) ast.Expr {
 			Args: []ast.Expr{d.value(c)},
 		}
 	case *constant.Float:
-		panic("support for value *constant.Float not yet implemented")
-	case *constant.Null:
-		// nothing to do.
-		return d.value(c)
+		// Floating-point literals are not addressable.
+		return d.deferInit(g)
+	case *constant.Null, *constant.ZeroInitializer, *constant.Undef:
+		// The zero value of the content type.
+		return &ast.CallExpr{
+			Fun:  ast.NewIdent("new"),
+			Args: []ast.Expr{d.goType(g.ContentType)},
+		}
 	// Complex constants
-	case *constant.Vector, *constant.Array, *constant.Struct:
+	case *constant.Vector, *constant.Array, *constant.CharArray, *constant.Struct:
+		if refersToGlobals(c) {
+			return d.deferInit(g)
+		}
 		return &ast.UnaryExpr{
 			Op: token.AND,
 			X:  d.value(c),
 		}
-	case *constant.ZeroInitializer:
-		return &ast.CallExpr{
-			Fun:  ast.NewIdent("new"),
-			Args: []ast.Expr{d.goType(c.Typ)},
-		}
-	// Global variable and function addresses
-	case *ir.Global:
-		// TODO: Check if `&g` should be returned instead of `g`.
-		return d.globalIdent(c.GlobalName)
-	case *ir.Func:
-		// TODO: Check if `&f` should be returned instead of `f`.
-		return d.globalIdent(c.GlobalName)
-	// Constant expressions
-	case constant.Expression:
-		panic("support for value constant.Expression not yet implemented")
+	// Global variable and function addresses, and constant expressions.
+	case *ir.Global, *ir.Func, constant.Expression:
+		// Global initializers referring to other globals may form initialization
+		// cycles in Go (e.g. a function referring to the global variable it
+		// initializes).
+		return d.deferInit(g)
 	default:
 		panic(fmt.Sprintf("support for value %T not yet implemented", c))
 	}
 }
 
+// deferInit returns a Go expression allocating the content of the given LLVM
+// IR global, the initializer of which is assigned by the init function of the
+// Go source file.
+//
+//    var g = new(T)
+//
+//    func init() {
+//       *g = init
+//    }
+func (d *decompiler) deferInit(g *ir.Global) ast.Expr {
+	assign := &ast.AssignStmt{
+		Lhs: []ast.Expr{&ast.StarExpr{X: d.globalIdent(g.GlobalName)}},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{d.value(g.Init)},
+	}
+	d.inits = append(d.inits, assign)
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent("new"),
+		Args: []ast.Expr{d.goType(g.ContentType)},
+	}
+}
+
+// funcAddr returns a Go expression of the address of the given LLVM IR
+// function. Go function values are not addressable, thus the address of a
+// package-level variable holding the function value is used instead; of
+// pointer to function type as the LLVM IR function (e.g. `*func()`).
+//
+//    &f_addr
+func (d *decompiler) funcAddr(f *ir.Func) ast.Expr {
+	d.funcAddrs[f.GlobalName] = f
+	return &ast.UnaryExpr{
+		Op: token.AND,
+		X:  d.funcAddrIdent(f.GlobalName),
+	}
+}
+
+// funcAddrIdent returns the identifier of the variable holding the function
+// value of the given LLVM IR function.
+func (d *decompiler) funcAddrIdent(name string) *ast.Ident {
+	return ast.NewIdent(d.globalIdent(name).Name + "_addr")
+}
+
+// funcAddrDecl returns a variable declaration of the variables holding the
+// function values of function addresses in use, and the assignments of their
+// function values; or nil if no function address is in use. The function
+// values are assigned by the init function of the Go source file, as functions
+// referring to their own address would otherwise form initialization cycles.
+//
+//    var f_addr func()
+//
+//    func init() {
+//       f_addr = f
+//    }
+func (d *decompiler) funcAddrDecl() (*ast.GenDecl, []ast.Stmt) {
+	if len(d.funcAddrs) == 0 {
+		return nil, nil
+	}
+	var names []string
+	for name := range d.funcAddrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	decl := &ast.GenDecl{
+		Tok: token.VAR,
+	}
+	if len(names) > 1 {
+		// Note, a valid left parenthesis position is required to print multiple
+		// variable specifications.
+		decl.Lparen = 1
+	}
+	var inits []ast.Stmt
+	for _, name := range names {
+		spec := &ast.ValueSpec{
+			Names: []*ast.Ident{d.funcAddrIdent(name)},
+			Type:  d.goType(d.funcAddrs[name].Sig),
+		}
+		decl.Specs = append(decl.Specs, spec)
+		assign := &ast.AssignStmt{
+			Lhs: []ast.Expr{d.funcAddrIdent(name)},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{d.globalIdent(name)},
+		}
+		inits = append(inits, assign)
+	}
+	return decl, inits
+}
+
+// refersToGlobals reports whether the given LLVM IR constant refers to global
+// variables or functions, either directly or through constant expressions.
+func refersToGlobals(c constant.Constant) bool {
+	switch c := c.(type) {
+	case *ir.Global, *ir.Func, constant.Expression:
+		return true
+	case *constant.Vector:
+		for _, elem := range c.Elems {
+			if refersToGlobals(elem) {
+				return true
+			}
+		}
+	case *constant.Array:
+		for _, elem := range c.Elems {
+			if refersToGlobals(elem) {
+				return true
+			}
+		}
+	case *constant.Struct:
+		for _, field := range c.Fields {
+			if refersToGlobals(field) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // funcDecl converts the given LLVM IR function into a corresponding Go function
 // declaration.
 func (d *decompiler) funcDecl(f *ir.Func, prims *primitive.Primitives) (*ast.FuncDecl, error) {
@@ -362,6 +707,10 @@ func (d *decompiler) funcDecl(f *ir.Func, prims *primitive.Primitives) (*ast.Fun
 	// Recover function declaration.
 	typ := d.goType(f.Sig)
 	sig := typ.(*ast.FuncType)
+	// Name parameters, as referred to by the function body.
+	for i, param := range f.Params {
+		sig.Params.List[i].Names = []*ast.Ident{d.localIdent(param.Name())}
+	}
 	fn := &ast.FuncDecl{
 		Name: d.globalIdent(f.GlobalName),
 		Type: sig,
@@ -391,6 +740,7 @@ func (d *decompiler) funcDecl(f *ir.Func, prims *primitive.Primitives) (*ast.Fun
 			for _, inc := range phi.Incs {
 				pred := d.blocks[inc.Pred.LocalName]
 				assignStmt := d.assign(phi.LocalName, d.value(inc.X))
+				d.origins[assignStmt] = phi
 				pred.out = append(pred.out, assignStmt)
 			}
 		}
@@ -398,8 +748,12 @@ func (d *decompiler) funcDecl(f *ir.Func, prims *primitive.Primitives) (*ast.Fun
 
 	/*
 
-		// Recover control flow primitives.
-		for _, prim := range prims {
+		// Recover control flow primitives, in bottom-up reduction order.
+		seqs, err := prims.Linearize(cfg.NewGraphFromFunc(f))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, prim := range seqs {
 			block, err := d.prim(prim)
 			if err != nil {
 				return nil, errors.WithStack(err)
@@ -424,7 +778,9 @@ func (d *decompiler) funcDecl(f *ir.Func, prims *primitive.Primitives) (*ast.Fun
 	sort.Sort(blocks)
 	for _, block := range blocks {
 		block.stmts = d.stmts(block)
-		block.stmts = append(block.stmts, d.term(block.Term))
+		termStmt := d.term(block.Term)
+		d.origins[termStmt] = block.Term
+		block.stmts = append(block.stmts, termStmt)
 	}
 
 	// Insert labels of target branches into corresponding basic blocks.
@@ -452,6 +808,11 @@ func (d *decompiler) funcDecl(f *ir.Func, prims *primitive.Primitives) (*ast.Fun
 	body := &ast.BlockStmt{
 		List: stmts,
 	}
+	// Declare local variables at the top of the function body, so that
+	// goto-statements never jump over variable declarations.
+	if decl := d.localDecl(f, body); decl != nil {
+		body.List = append([]ast.Stmt{decl}, body.List...)
+	}
 	fn.Body = body
 	return fn, nil
 }
@@ -518,9 +879,12 @@ func (d *decompiler) label(name string) *ast.Ident {
 func (d *decompiler) value(v value.Value) ast.Expr {
 	switch v := v.(type) {
 	case value.Named:
-		switch v.(type) {
-		case *ir.Global, *ir.Func:
+		switch v := v.(type) {
+		case *ir.Global:
 			return d.globalIdent(v.Name())
+		case *ir.Func:
+			// Function address.
+			return d.funcAddr(v)
 		default:
 			return d.localIdent(v.Name())
 		}
@@ -548,6 +912,17 @@ func (d *decompiler) uintLit(i uint64) ast.Expr {
 	}
 }
 
+// pkgSelector returns the Go expression `pkg.name` of the given exported
+// identifier of the standard package with the specified import path, and
+// records the use of the package.
+func (d *decompiler) pkgSelector(path, name string) *ast.SelectorExpr {
+	d.imports[path] = true
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent(pathpkg.Base(path)),
+		Sel: ast.NewIdent(name),
+	}
+}
+
 // basicBlock represents a conceptual basic block, that may contain both LLVM IR
 // instructions and Go statements.
 type basicBlock struct {
@@ -581,14 +956,15 @@ func (d *decompiler) stmts(block *basicBlock) []ast.Stmt {
 }
 
 // parsePrims parses the JSON file containing a mapping of control flow
-// primitives for the given function.
-func parsePrims(srcName string, f *ir.Func) (*primitive.Primitives, error) {
+// primitives for the given function. If not present, the primitives are
+// generated by control flow analysis, limited to the given resource limits.
+func parsePrims(srcName string, f *ir.Func, entry string, limits interval.Limits) (*primitive.Primitives, error) {
 	graphsDir := fmt.Sprintf("%s_graphs", srcName)
 	jsonName := f.GlobalName + ".json"
 	jsonPath := filepath.Join(graphsDir, jsonName)
 	// Generate primitives if not present on file system.
 	if !osutil.Exists(jsonPath) {
-		prims, err := genPrims(f)
+		prims, err := genPrims(f, entry, limits)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -596,15 +972,13 @@ func parsePrims(srcName string, f *ir.Func) (*primitive.Primitives, error) {
 	}
 	// Parse primitives from file system.
 	dbg.Println("loading primitives:", jsonPath)
-	var prims *primitive.Primitives
 	fr, err := os.Open(jsonPath)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	defer fr.Close()
-	r := bufio.NewReader(fr)
-	dec := json.NewDecoder(r)
-	if err := dec.Decode(&prims); err != nil {
+	prims, err := primitive.Decode(bufio.NewReader(fr))
+	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	return prims, nil
@@ -613,33 +987,19 @@ func parsePrims(srcName string, f *ir.Func) (*primitive.Primitives, error) {
 // ErrIncomplete signals an incomplete control flow recovery.
 var ErrIncomplete = goerrors.New("incomplete control flow recovery")
 
-// locateEntryNode attempts to locate the entry node of the control flow graph
-// by searching for a single node in the control flow graph with no incoming
-// edges.
-func locateEntryNode(g *cfg.Graph) (graph.Node, error) {
-	var entry graph.Node
-	nodes := g.Nodes()
-	for nodes.Next() {
-		n := nodes.Node()
-		preds := g.To(n.ID())
-		if preds.Len() == 0 {
-			if entry != nil {
-				return nil, errors.Errorf("more than one candidate for the entry node located; prev %q, new %q", label(entry), label(n))
-			}
-			entry = n
-		}
-	}
-	if entry == nil {
-		return nil, errors.Errorf("unable to locate entry node; try specifying an entry node label using the -entry flag")
-	}
-	return entry, nil
-}
+// ErrTypeCheck signals type errors in the generated Go source code, as reported
+// by -check.
+var ErrTypeCheck = goerrors.New("type errors in generated Go source code")
 
 // genPrims returns the high-level primitives of the given function discovered
-// by control flow analysis.
-func genPrims(f *ir.Func) (*primitive.Primitives, error) {
+// by control flow analysis, limited to the given resource limits. The entry
+// node is specified by the given label, if non-empty.
+func genPrims(f *ir.Func, entry string, limits interval.Limits) (*primitive.Primitives, error) {
 	g := cfg.NewGraphFromFunc(f)
-	prims := interval.Analyze(g)
+	if err := reader.SetEntry(g, entry); err != nil {
+		return nil, errors.Errorf("unable to set entry node of function %q; %v", f.Ident(), err)
+	}
+	prims := interval.Analyze(context.Background(), g, limits, nil)
 	return prims, nil
 }
 
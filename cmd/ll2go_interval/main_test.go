@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	irtypes "github.com/llir/llvm/ir/types"
+	"github.com/mewmew/cfa/interval"
+)
+
+func TestGlobalDecl(t *testing.T) {
+	var (
+		str = newGlobal("str", &irtypes.ArrayType{Len: 3, ElemType: irtypes.I8}, &constant.CharArray{Typ: &irtypes.ArrayType{Len: 3, ElemType: irtypes.I8}, X: []byte("h\xC8\x00")})
+		x   = newGlobal("x", irtypes.I32, &constant.Int{Typ: irtypes.I32, X: big.NewInt(42)})
+		f   = newFunc("f", &irtypes.FuncType{RetType: irtypes.Void})
+	)
+	golden := []struct {
+		g        *ir.Global
+		want     string
+		wantInit string
+		// Functions the addresses of which are used.
+		funcAddrs []string
+	}{
+		// @str = global [3 x i8] c"h\C8\00"
+		{
+			g:    str,
+			want: "var str *[3]int8 = &[3]int8{104, -56, 0}",
+		},
+		// @x = global i32 42
+		{
+			g:    x,
+			want: "var x *int32 = newInt32(42)",
+		},
+		// @f = global double 1.5
+		{
+			g:        newGlobal("f", irtypes.Double, &constant.Float{Typ: irtypes.Double, X: big.NewFloat(1.5)}),
+			want:     "var f *float64 = new(float64)",
+			wantInit: "*f = 1.5",
+		},
+		// @p = global i32* null
+		{
+			g:    newGlobal("p", &irtypes.PointerType{ElemType: irtypes.I32}, &constant.Null{Typ: &irtypes.PointerType{ElemType: irtypes.I32}}),
+			want: "var p **int32 = new(*int32)",
+		},
+		// @q = global i32* @x
+		{
+			g:        newGlobal("q", &irtypes.PointerType{ElemType: irtypes.I32}, x),
+			want:     "var q **int32 = new(*int32)",
+			wantInit: "*q = x",
+		},
+		// @tbl = global [1 x i32*] [i32* @x]
+		{
+			g:        newGlobal("tbl", &irtypes.ArrayType{Len: 1, ElemType: &irtypes.PointerType{ElemType: irtypes.I32}}, &constant.Array{Typ: &irtypes.ArrayType{Len: 1, ElemType: &irtypes.PointerType{ElemType: irtypes.I32}}, Elems: []constant.Constant{x}}),
+			want:     "var tbl *[1]*int32 = new([1]*int32)",
+			wantInit: "*tbl = [1]*int32{x}",
+		},
+		// @y = global i32 select (i1 icmp eq (i32 1, i32 2), i32 3, i32 4)
+		{
+			g: newGlobal("y", irtypes.I32, &constant.ExprSelect{
+				Cond: &constant.ExprICmp{Pred: enum.IPredEQ, X: &constant.Int{Typ: irtypes.I32, X: big.NewInt(1)}, Y: &constant.Int{Typ: irtypes.I32, X: big.NewInt(2)}},
+				X:    &constant.Int{Typ: irtypes.I32, X: big.NewInt(3)},
+				Y:    &constant.Int{Typ: irtypes.I32, X: big.NewInt(4)},
+			}),
+			want:     "var y *int32 = new(int32)",
+			wantInit: "*y = func() int32 {\n\tif 1 == 2 {\n\t\treturn 3\n\t}\n\treturn 4\n}()",
+		},
+		// @fp = global void ()* @f
+		{
+			g:         newGlobal("fp", f.Typ, f),
+			want:      "var fp **func() = new(*func())",
+			wantInit:  "*fp = &f_addr",
+			funcAddrs: []string{"f"},
+		},
+		// @p = global i8* bitcast (void ()* @f to i8*)
+		{
+			g:         newGlobal("p", irtypes.I8Ptr, &constant.ExprBitCast{From: f, To: irtypes.I8Ptr}),
+			want:      "var p **int8 = new(*int8)",
+			wantInit:  "*p = (*int8)(unsafe.Pointer(&f_addr))",
+			funcAddrs: []string{"f"},
+		},
+	}
+	for i, gold := range golden {
+		d := newDecompiler()
+		got := printNode(t, d.globalDecl(gold.g))
+		if got != gold.want {
+			t.Errorf("i=%d: global declaration mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+		var inits []string
+		for _, stmt := range d.inits {
+			inits = append(inits, printNode(t, stmt))
+		}
+		if gotInit := strings.Join(inits, "\n"); gotInit != gold.wantInit {
+			t.Errorf("i=%d: global initializer mismatch; expected `%s`, got `%s`", i, gold.wantInit, gotInit)
+		}
+		var funcAddrs []string
+		for name := range d.funcAddrs {
+			funcAddrs = append(funcAddrs, name)
+		}
+		if !reflect.DeepEqual(funcAddrs, gold.funcAddrs) {
+			t.Errorf("i=%d: function addresses mismatch; expected %q, got %q", i, gold.funcAddrs, funcAddrs)
+		}
+	}
+}
+
+func TestImportDecl(t *testing.T) {
+	golden := []struct {
+		imports map[string]bool
+		want    string
+	}{
+		{
+			imports: map[string]bool{"unsafe": true},
+			want:    `import "unsafe"`,
+		},
+		{
+			imports: map[string]bool{"unsafe": true, "math/big": true, "os": true, "math": true, "fmt": true},
+			want:    "import (\n\t\"fmt\"\n\t\"math\"\n\t\"math/big\"\n\t\"os\"\n\t\"unsafe\"\n)",
+		},
+	}
+	for i, gold := range golden {
+		got := printNode(t, importDecl(gold.imports))
+		if got != gold.want {
+			t.Errorf("i=%d: import declaration mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+	}
+}
+
+func TestPkgSelector(t *testing.T) {
+	d := newDecompiler()
+	got := printNode(t, d.pkgSelector("math/bits", "LeadingZeros32"))
+	want := "bits.LeadingZeros32"
+	if got != want {
+		t.Errorf("selector mismatch; expected `%s`, got `%s`", want, got)
+	}
+	if !d.imports["math/bits"] {
+		t.Errorf("use of package %q not recorded", "math/bits")
+	}
+}
+
+func TestLL2Go(t *testing.T) {
+	golden := []struct {
+		llPath string
+	}{
+		// Loop containing an if-statement, the conditions of which are i1 values.
+		{llPath: "testdata/loop_if.ll"},
+		// Global initializers referring to the address of a function.
+		{llPath: "testdata/func_addr.ll"},
+	}
+	for i, gold := range golden {
+		file, err := ll2go(gold.llPath, nil, "", interval.Limits{}, 1, false)
+		if err != nil {
+			t.Errorf("i=%d: unable to decompile %q; %v", i, gold.llPath, err)
+			continue
+		}
+		// Type-check the generated Go source code.
+		buf := &bytes.Buffer{}
+		if err := printer.Fprint(buf, token.NewFileSet(), file); err != nil {
+			t.Errorf("i=%d: unable to print Go source code; %v", i, err)
+			continue
+		}
+		fset := token.NewFileSet()
+		parsed, err := parser.ParseFile(fset, "out.go", buf.Bytes(), 0)
+		if err != nil {
+			t.Errorf("i=%d: unable to parse Go source code; %v\n%s", i, err, buf)
+			continue
+		}
+		conf := types.Config{Importer: importer.Default()}
+		if _, err := conf.Check(parsed.Name.Name, fset, []*ast.File{parsed}, nil); err != nil {
+			t.Errorf("i=%d: type error in Go source code of %q; %v\n%s", i, gold.llPath, err, buf)
+		}
+	}
+}
+
+func TestLL2GoCheck(t *testing.T) {
+	golden := []struct {
+		llPath string
+	}{
+		{llPath: "testdata/loop_if.ll"},
+		{llPath: "testdata/func_addr.ll"},
+	}
+	for i, gold := range golden {
+		if _, err := ll2go(gold.llPath, nil, "", interval.Limits{}, 1, true); err != nil {
+			t.Errorf("i=%d: type-check of %q failed; %v", i, gold.llPath, err)
+		}
+	}
+}
+
+// newFunc returns a new LLVM IR function declaration of the given name and
+// signature.
+func newFunc(name string, sig *irtypes.FuncType) *ir.Func {
+	return &ir.Func{
+		GlobalIdent: ir.GlobalIdent{GlobalName: name},
+		Sig:         sig,
+		Typ:         &irtypes.PointerType{ElemType: sig},
+	}
+}
+
+// newGlobal returns a new LLVM IR global variable of the given name, content
+// type and initializer.
+func newGlobal(name string, contentType irtypes.Type, init constant.Constant) *ir.Global {
+	return &ir.Global{
+		GlobalIdent: ir.GlobalIdent{GlobalName: name},
+		ContentType: contentType,
+		Init:        init,
+		Typ:         &irtypes.PointerType{ElemType: contentType},
+	}
+}
@@ -4,7 +4,7 @@ package main
 
 // prim merges the basic blocks of the given primitive into a corresponding
 // conceputal basic block for the primitive.
-func (d *decompiler) prim(prim *primitive.Primitive) (*basicBlock, error) {
+func (d *decompiler) prim(prim *primitive.Sequential) (*basicBlock, error) {
 	switch prim.Prim {
 	case "if":
 		condName := prim.Nodes["cond"]
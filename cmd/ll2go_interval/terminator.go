@@ -79,9 +79,10 @@ func (d *decompiler) termSwitch(term *ir.TermSwitch) ast.Stmt {
 	// Use goto-statements as a fallback for incomplete control flow recovery.
 	var cases []ast.Stmt
 	for _, c := range term.Cases {
+		d.labels[c.Target.LocalName] = true
 		gotoStmt := &ast.BranchStmt{
 			Tok:   token.GOTO,
-			Label: d.localIdent(c.Target.LocalName),
+			Label: d.label(c.Target.LocalName),
 		}
 		cc := &ast.CaseClause{
 			List: []ast.Expr{d.value(c.X)},
@@ -89,6 +90,15 @@ func (d *decompiler) termSwitch(term *ir.TermSwitch) ast.Stmt {
 		}
 		cases = append(cases, cc)
 	}
+	d.labels[term.TargetDefault.LocalName] = true
+	gotoDefaultStmt := &ast.BranchStmt{
+		Tok:   token.GOTO,
+		Label: d.label(term.TargetDefault.LocalName),
+	}
+	defaultCase := &ast.CaseClause{
+		Body: []ast.Stmt{gotoDefaultStmt},
+	}
+	cases = append(cases, defaultCase)
 	return &ast.SwitchStmt{
 		Tag: d.value(term.X),
 		Body: &ast.BlockStmt{
@@ -0,0 +1,9 @@
+; Global initializers referring to the address of a function.
+
+@fp = global void ()* @f
+@p = global i8* bitcast (void ()* @f to i8*)
+
+define void @f() {
+entry:
+	ret void
+}
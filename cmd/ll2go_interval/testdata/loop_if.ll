@@ -0,0 +1,42 @@
+; Sum of the odd integers below n, or of all integers below n if @all is set.
+;
+;    int sum(int n) {
+;       int sum = 0;
+;       for (int i = 0; i < n; i++) {
+;          if ((i & 1) || all) {
+;             sum += i;
+;          }
+;       }
+;       return sum;
+;    }
+
+@all = global i1 false
+
+define i32 @sum(i32 %n) {
+entry:
+	br label %loop
+
+loop:
+	%i = phi i32 [ 0, %entry ], [ %i.next, %latch ]
+	%sum = phi i32 [ 0, %entry ], [ %sum.next, %latch ]
+	%cond = icmp slt i32 %i, %n
+	br i1 %cond, label %body, label %exit
+
+body:
+	%odd = trunc i32 %i to i1
+	%all.val = load i1, i1* @all
+	%add_cond = or i1 %odd, %all.val
+	br i1 %add_cond, label %add, label %latch
+
+add:
+	%sum.add = add i32 %sum, %i
+	br label %latch
+
+latch:
+	%sum.next = phi i32 [ %sum.add, %add ], [ %sum, %body ]
+	%i.next = add i32 %i, 1
+	br label %loop
+
+exit:
+	ret i32 %sum
+}
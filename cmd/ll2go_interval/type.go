@@ -56,7 +56,17 @@ func (d *decompiler) goTypeDef(t irtypes.Type) ast.Expr {
 			Results: results,
 		}
 	case *irtypes.IntType:
+		if isBool(t) {
+			// i1 is represented by bool; see bool.go.
+			return ast.NewIdent("bool")
+		}
 		d.intSizes[t.BitSize] = true
+		if isWide(t) {
+			// *big.Int
+			return &ast.StarExpr{
+				X: d.pkgSelector("math/big", "Int"),
+			}
+		}
 		return &ast.Ident{
 			Name: fmt.Sprintf("int%d", t.BitSize),
 		}
@@ -116,3 +126,27 @@ func (d *decompiler) goTypeDef(t irtypes.Type) ast.Expr {
 		panic(fmt.Sprintf("support for type %T not yet implemented", t))
 	}
 }
+
+// backingSize returns the bit size of the builtin Go integer type underlying
+// the integer type of the given bit size (at most 64 bits).
+func backingSize(bitSize uint64) uint64 {
+	switch {
+	case bitSize <= 8:
+		return 8
+	case bitSize <= 16:
+		return 16
+	case bitSize <= 32:
+		return 32
+	default:
+		return 64
+	}
+}
+
+// uintType returns the unsigned Go integer type corresponding to the given LLVM
+// IR integer type.
+func (d *decompiler) uintType(t *irtypes.IntType) ast.Expr {
+	d.uintSizes[t.BitSize] = true
+	return &ast.Ident{
+		Name: fmt.Sprintf("uint%d", t.BitSize),
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math/big"
+	"strconv"
+
+	irtypes "github.com/llir/llvm/ir/types"
+	"github.com/pkg/errors"
+)
+
+// Integer types wider than 64 bits are represented by immutable *big.Int values
+// in the generated Go source code (e.g. `type int128 = *big.Int`). Every
+// operation allocates a new *big.Int for its result, which is reduced modulo
+// 2^N to the signed range of the N-bit integer type using wrapInt.
+
+// wideIntHelpers is the Go source code of the helper functions used by wide
+// integer operations.
+const wideIntHelpers = `package p
+
+// wrapInt reduces x modulo 2^n to the range of n-bit signed integers.
+func wrapInt(x *big.Int, n uint) *big.Int {
+	m := new(big.Int).Lsh(big.NewInt(1), n)
+	y := new(big.Int).Mod(x, m)
+	if y.Bit(int(n-1)) == 1 {
+		y.Sub(y, m)
+	}
+	return y
+}
+
+// uwrapInt reduces x modulo 2^n to the range of n-bit unsigned integers.
+func uwrapInt(x *big.Int, n uint) *big.Int {
+	m := new(big.Int).Lsh(big.NewInt(1), n)
+	return new(big.Int).Mod(x, m)
+}
+
+// bigInt returns the integer of the given base 10 string representation.
+func bigInt(s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid integer " + s)
+	}
+	return x
+}
+`
+
+// wideIntDecls returns the declarations of the helper functions used by wide
+// integer operations.
+func wideIntDecls() ([]ast.Decl, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", wideIntHelpers, 0)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return file.Decls, nil
+}
+
+// isWide reports whether the given LLVM IR integer type is wider than 64 bits,
+// and thus represented by *big.Int.
+func isWide(t *irtypes.IntType) bool {
+	return t.BitSize > 64
+}
+
+// usesWideInts reports whether the given integer bit sizes contain an integer
+// type wider than 64 bits.
+func usesWideInts(sizes map[uint64]bool) bool {
+	for size := range sizes {
+		if size > 64 {
+			return true
+		}
+	}
+	return false
+}
+
+// wideBinaryOp returns a Go expression of the given binary operation on wide
+// integers of type t, with signed semantics; e.g.
+// `wrapInt(new(big.Int).Add(x, y), 128)`.
+func (d *decompiler) wideBinaryOp(t *irtypes.IntType, x ast.Expr, op token.Token, y ast.Expr) ast.Expr {
+	switch op {
+	case token.ADD:
+		return wrapInt(t, d.bigOp("Add", x, y))
+	case token.SUB:
+		return wrapInt(t, d.bigOp("Sub", x, y))
+	case token.MUL:
+		return wrapInt(t, d.bigOp("Mul", x, y))
+	case token.QUO:
+		// Quo truncates towards zero, as sdiv.
+		return wrapInt(t, d.bigOp("Quo", x, y))
+	case token.REM:
+		// Rem takes the sign of the dividend, as srem.
+		return d.bigOp("Rem", x, y)
+	case token.AND:
+		return d.bigOp("And", x, y)
+	case token.OR:
+		return d.bigOp("Or", x, y)
+	case token.XOR:
+		return d.bigOp("Xor", x, y)
+	case token.SHL:
+		return wrapInt(t, d.bigOp("Lsh", x, shiftCount(y)))
+	case token.SHR:
+		// Rsh performs arithmetic shift right on negative integers, as ashr.
+		return d.bigOp("Rsh", x, shiftCount(y))
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		// x.Cmp(y) op 0
+		cmp := &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   x,
+				Sel: ast.NewIdent("Cmp"),
+			},
+			Args: []ast.Expr{y},
+		}
+		return &ast.BinaryExpr{
+			X:  cmp,
+			Op: op,
+			Y:  &ast.BasicLit{Kind: token.INT, Value: "0"},
+		}
+	default:
+		panic(fmt.Sprintf("support for wide integer operator %v not yet implemented", op))
+	}
+}
+
+// wideConvert returns a Go expression for converting the given Go expression x
+// of LLVM IR type from into the specified integer type, where either type is a
+// wide integer. The boolean unsigned specifies whether x is interpreted as
+// unsigned (zext), or signed (sext, trunc).
+func (d *decompiler) wideConvert(x ast.Expr, from, to *irtypes.IntType, unsigned bool) ast.Expr {
+	switch {
+	case isWide(from) && isWide(to):
+		if unsigned {
+			return uwrapInt(from, x)
+		}
+		return wrapInt(to, x)
+	case isWide(from):
+		// intN(wrapInt(x, N).Int64())
+		v := &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   wrapInt(to, x),
+				Sel: ast.NewIdent("Int64"),
+			},
+		}
+		return &ast.CallExpr{
+			Fun:  d.goType(to),
+			Args: []ast.Expr{v},
+		}
+	default:
+		// Note, x is already converted to an unsigned integer by the caller if
+		// unsigned.
+		name, method := "int64", "NewInt"
+		if unsigned {
+			name, method = "uint64", "SetUint64"
+		}
+		v := &ast.CallExpr{
+			Fun:  ast.NewIdent(name),
+			Args: []ast.Expr{x},
+		}
+		if unsigned {
+			return d.bigOp(method, v)
+		}
+		return &ast.CallExpr{
+			Fun:  d.pkgSelector("math/big", method),
+			Args: []ast.Expr{v},
+		}
+	}
+}
+
+// wideConst returns a Go expression of the given wide integer constant.
+func (d *decompiler) wideConst(x *big.Int) ast.Expr {
+	if x.IsInt64() {
+		// big.NewInt(x)
+		return &ast.CallExpr{
+			Fun:  d.pkgSelector("math/big", "NewInt"),
+			Args: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: x.String()}},
+		}
+	}
+	// bigInt("x")
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent("bigInt"),
+		Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(x.String())}},
+	}
+}
+
+// bigOp returns the Go expression `new(big.Int).method(args...)`.
+func (d *decompiler) bigOp(method string, args ...ast.Expr) ast.Expr {
+	z := &ast.CallExpr{
+		Fun:  ast.NewIdent("new"),
+		Args: []ast.Expr{d.pkgSelector("math/big", "Int")},
+	}
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   z,
+			Sel: ast.NewIdent(method),
+		},
+		Args: args,
+	}
+}
+
+// shiftCount returns the Go expression `uint(y.Uint64())` of the given wide
+// integer shift count.
+func shiftCount(y ast.Expr) ast.Expr {
+	v := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   y,
+			Sel: ast.NewIdent("Uint64"),
+		},
+	}
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent("uint"),
+		Args: []ast.Expr{v},
+	}
+}
+
+// wrapInt returns the Go expression `wrapInt(x, N)` of the given integer type.
+func wrapInt(t *irtypes.IntType, x ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent("wrapInt"),
+		Args: []ast.Expr{x, &ast.BasicLit{Kind: token.INT, Value: strconv.FormatUint(t.BitSize, 10)}},
+	}
+}
+
+// uwrapInt returns the Go expression `uwrapInt(x, N)` of the given integer
+// type.
+func uwrapInt(t *irtypes.IntType, x ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent("uwrapInt"),
+		Args: []ast.Expr{x, &ast.BasicLit{Kind: token.INT, Value: strconv.FormatUint(t.BitSize, 10)}},
+	}
+}
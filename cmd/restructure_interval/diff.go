@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mewkiz/pkg/pathutil"
+	"github.com/mewmew/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+// diff compares the control flow primitives of the given JSON files, and
+// reports the added, removed and changed primitives of each function to
+// standard output. If both paths are directories (e.g. "foo_graphs"), the JSON
+// files of functions with the same name are compared. The boolean return value
+// reports whether any differences were found.
+func diff(aPath, bPath string) (bool, error) {
+	aFuncs, err := funcPaths(aPath)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	bFuncs, err := funcPaths(bPath)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if len(aFuncs) == 1 && len(bFuncs) == 1 {
+		// Compare single files regardless of function name.
+		for funcName := range aFuncs {
+			for _, bJSONPath := range bFuncs {
+				bFuncs = map[string]string{funcName: bJSONPath}
+			}
+		}
+	}
+	funcNames := make(map[string]bool)
+	for funcName := range aFuncs {
+		funcNames[funcName] = true
+	}
+	for funcName := range bFuncs {
+		funcNames[funcName] = true
+	}
+	var names []string
+	for funcName := range funcNames {
+		names = append(names, funcName)
+	}
+	sort.Strings(names)
+	differs := false
+	for _, funcName := range names {
+		aJSONPath, aOk := aFuncs[funcName]
+		bJSONPath, bOk := bFuncs[funcName]
+		switch {
+		case !aOk:
+			fmt.Printf("%s: added function\n", funcName)
+			differs = true
+			continue
+		case !bOk:
+			fmt.Printf("%s: removed function\n", funcName)
+			differs = true
+			continue
+		}
+		a, err := decodePrims(aJSONPath)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		b, err := decodePrims(bJSONPath)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		for _, change := range primitive.Diff(a, b) {
+			fmt.Printf("%s: %v\n", funcName, change)
+			differs = true
+		}
+	}
+	return differs, nil
+}
+
+// funcPaths returns a map from function name to JSON file path of the given
+// path, which is either a JSON file or a directory of JSON files.
+func funcPaths(path string) (map[string]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !fi.IsDir() {
+		return map[string]string{pathutil.FileName(path): path}, nil
+	}
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	m := make(map[string]string)
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+		m[pathutil.FileName(fi.Name())] = filepath.Join(path, fi.Name())
+	}
+	return m, nil
+}
+
+// decodePrims decodes the control flow primitives of the given JSON file.
+func decodePrims(jsonPath string) (*primitive.Primitives, error) {
+	f, err := os.Open(jsonPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	prims, err := primitive.Decode(f)
+	if err != nil {
+		return nil, errors.Errorf("unable to decode primitives of %q; %v", jsonPath, err)
+	}
+	return prims, nil
+}
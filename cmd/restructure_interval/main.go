@@ -1,35 +1,372 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/graphism/exp/cfg"
+	"github.com/llir/llvm/asm"
+	"github.com/llir/llvm/ir"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/mewkiz/pkg/pathutil"
 	"github.com/mewmew/cfa/interval"
+	"github.com/mewmew/cfa/reader"
+	"github.com/mewmew/cfa/render"
 	"github.com/pkg/errors"
 )
 
 func main() {
+	var opts options
+	flag.StringVar(&opts.derivedDir, "derived", "", "output directory for DOT files of the derived sequence of graphs (DIR/<src>/<func>/G1.dot)")
+	flag.StringVar(&opts.svgDir, "svg", "", "output directory for SVG files of the derived sequence of graphs (DIR/<src>/<func>/G1.svg)")
+	flag.BoolVar(&opts.annotate, "annotate", false, "output DOT file annotated with control flow primitives (foo.dot -> foo_annotated.dot)")
+	flag.BoolVar(&opts.residual, "residual", false, "output residual DOT file of unstructured control flow (foo.dot -> foo_residual.dot)")
+	flag.BoolVar(&opts.decomp, "decomp", false, "output sequential primitives of decomp, in bottom-up reduction order")
+	flag.StringVar(&opts.format, "format", "json", "output format of control flow primitives (json or pseudo)")
+	flag.StringVar(&opts.outDir, "o", "", "output directory of JSON files, using the layout read by ll2go (DIR/<src>_graphs/<func>.json); the control flow graph of each function of LLVM IR files is written alongside (DIR/<src>_graphs/<func>.dot), and primitives are printed to standard output if not set")
+	flag.BoolVar(&opts.force, "force", false, "force overwrite existing output files")
+	flag.StringVar(&opts.entry, "entry", "", "label of the entry node (default: node labelled entry, or the single node without incoming edges)")
+	flag.StringVar(&opts.funcs, "funcs", "", "comma-separated list of functions to restructure of LLVM IR files")
+	flag.StringVar(&opts.srcName, "src", "", "source name of -o (default: <src> of the parent directory <src>_graphs of each DOT file)")
+	flag.BoolVar(&opts.verbose, "v", false, "log the decisions made during control flow analysis")
+	jobs := flag.Int("j", 1, "number of DOT files to restructure concurrently")
 	flag.Parse()
-	for _, dotPath := range flag.Args() {
-		if err := restructure(dotPath); err != nil {
+	// Compare primitives if invoked as `restructure_interval diff a.json b.json`.
+	// As with diff(1), the exit status is 1 if differences were found and 2 on
+	// error.
+	if flag.Arg(0) == "diff" {
+		if flag.NArg() != 3 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		differs, err := diff(flag.Arg(1), flag.Arg(2))
+		if err != nil {
+			log.Printf("%+v", err)
+			os.Exit(2)
+		}
+		if differs {
+			os.Exit(1)
+		}
+		return
+	}
+	if *jobs < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	switch opts.format {
+	case "json", "pseudo":
+		// valid output format.
+	default:
+		log.Fatalf("invalid output format %q; expected json or pseudo", opts.format)
+	}
+	// Read control flow graph from standard input if no input files are
+	// specified.
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	var tasks []*task
+	for _, path := range paths {
+		ts, err := parseTasks(path, opts)
+		if err != nil {
 			log.Fatalf("%+v", err)
 		}
+		tasks = append(tasks, ts...)
+	}
+	outputs := make([][]byte, len(tasks))
+	errs := make([]error, len(tasks))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < *jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				outputs[i], errs[i] = restructure(tasks[i], opts)
+			}
+		}()
+	}
+	for i := range tasks {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	// Print output in the order of the input files, regardless of the number of
+	// jobs.
+	for i, output := range outputs {
+		if errs[i] != nil {
+			log.Fatalf("%+v", errs[i])
+		}
+		if output == nil {
+			// Output written to JSON file.
+			continue
+		}
+		fmt.Println(string(output))
 	}
 }
 
-func restructure(dotPath string) error {
-	g, err := cfg.ParseFile(dotPath)
+// options specifies the output options of restructure_interval.
+type options struct {
+	// Output directory of the derived sequence of graphs in DOT format, using
+	// the <src>/<func>/G1.dot layout.
+	derivedDir string
+	// Output directory of the derived sequence of graphs in SVG format, using
+	// the <src>/<func>/G1.svg layout.
+	svgDir string
+	// Output DOT files annotated with the recovered control flow primitives.
+	annotate bool
+	// Output DOT files of the residual graph after collapsing the recovered
+	// control flow primitives.
+	residual bool
+	// Output sequential primitives in the format of decomp.
+	decomp bool
+	// Output format of the control flow primitives; either "json" or "pseudo".
+	format string
+	// Output directory of JSON files, using the <src>_graphs/<func>.json layout
+	// read by ll2go; or empty to print the primitives to standard output. The
+	// DOT files of functions of LLVM IR files are only written if set.
+	outDir string
+	// Force overwrite existing output files.
+	force bool
+	// Label of the entry node; or empty to use the node labelled "entry", or
+	// the single node without incoming edges.
+	entry string
+	// Comma-separated list of functions to restructure of LLVM IR files; or
+	// empty to restructure all functions.
+	funcs string
+	// Source name of the output directory; or empty to use the source name of
+	// the parent directory of each DOT file, or of each LLVM IR file.
+	srcName string
+	// Log the decisions made during control flow analysis.
+	verbose bool
+}
+
+// A task is a control flow graph to restructure.
+type task struct {
+	// Path of the input file of the control flow graph (DOT, JSON adjacency
+	// list, edge list or GraphML); or "-" for standard input.
+	path string
+	// LLVM IR function of the control flow graph, the DOT file of which is
+	// written to path if -o is set; or nil if the control flow graph is parsed
+	// from path.
+	f *ir.Func
+}
+
+// parseTasks returns the control flow graphs to restructure of the given input
+// file, which is either a control flow graph supported by the reader package or
+// an LLVM IR file. The control flow graph of each function of LLVM IR files is
+// stored in the <src>_graphs/<func>.dot layout of the output directory, if
+// specified.
+func parseTasks(path string, opts options) ([]*task, error) {
+	if filepath.Ext(path) != ".ll" {
+		return []*task{{path: path}}, nil
+	}
+	module, err := asm.ParseFile(path)
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, errors.WithStack(err)
+	}
+	funcNames := make(map[string]bool)
+	for _, funcName := range strings.Split(opts.funcs, ",") {
+		if len(funcName) == 0 {
+			continue
+		}
+		funcNames[funcName] = true
+	}
+	srcName := opts.srcName
+	if len(srcName) == 0 {
+		srcName = pathutil.FileName(path)
+	}
+	graphsDir := filepath.Join(opts.outDir, fmt.Sprintf("%s_graphs", srcName))
+	var tasks []*task
+	for _, f := range module.Funcs {
+		if len(funcNames) > 0 && !funcNames[f.GlobalName] {
+			continue
+		}
+		if len(f.Blocks) == 0 {
+			// Skip function declarations.
+			continue
+		}
+		t := &task{
+			path: filepath.Join(graphsDir, f.GlobalName+".dot"),
+			f:    f,
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+// restructure recovers the control flow primitives of the given control flow
+// graph, and returns them in JSON format; or nil if written to a JSON file. If
+// the output format is pseudo, a pseudo-code skeleton is returned instead.
+func restructure(t *task, opts options) ([]byte, error) {
+	var g *cfg.Graph
+	if t.f != nil {
+		g = cfg.NewGraphFromFunc(t.f)
+	} else {
+		var err error
+		g, err = reader.ParseFile(t.path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if err := reader.SetEntry(g, opts.entry); err != nil {
+		return nil, errors.Errorf("unable to set entry node of %q; %v; use -entry to specify", t.path, err)
+	}
+	if t.f != nil && len(opts.outDir) > 0 {
+		// Note, the DOT file is written before analysis, as control flow
+		// analysis records its state in the graph.
+		if err := writeFile(t.path, []byte(g.String()), opts.force); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	path := t.path
+	if path == "-" {
+		// Base name of output files of standard input.
+		path = "stdin.dot"
+	}
+	derived := &derivedGraphs{}
+	observers := []interval.Observer{derived}
+	if opts.verbose {
+		l := log.New(os.Stderr, fmt.Sprintf("%s: ", path), 0)
+		observers = append(observers, interval.NewLogObserver(l))
+	}
+	var dotWriter *interval.DOTWriter
+	if len(opts.derivedDir) > 0 {
+		dotWriter = interval.NewDOTWriter(funcDir(opts.derivedDir, path, opts))
+		observers = append(observers, dotWriter)
 	}
-	prims := interval.Analyze(g)
-	buf, err := json.MarshalIndent(prims, "", "\t")
+	prims := interval.Analyze(context.Background(), g, interval.Limits{}, interval.MultiObserver(observers...))
+	if dotWriter != nil {
+		if err := dotWriter.Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if opts.annotate {
+		annotatedPath := pathutil.TrimExt(path) + "_annotated.dot"
+		if err := writeFile(annotatedPath, []byte(render.DOT(g, prims)), opts.force); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if opts.residual {
+		h, _, err := prims.Reduce(g)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if n := h.Nodes().Len(); n > 1 {
+			log.Printf("control flow graph %q not fully structured; %d nodes remaining", path, n)
+		}
+		residualPath := pathutil.TrimExt(path) + "_residual.dot"
+		if err := writeFile(residualPath, []byte(h.String()), opts.force); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if len(opts.svgDir) > 0 {
+		svgDir := funcDir(opts.svgDir, path, opts)
+		for i, Gi := range derived.gs {
+			svgPath := filepath.Join(svgDir, fmt.Sprintf("G%d.svg", i+1))
+			if err := writeFile(svgPath, []byte(render.SVG(Gi, prims)), opts.force); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+	var v interface{} = prims
+	if opts.decomp {
+		seqs, err := prims.Linearize(g)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		v = seqs
+	}
+	buf, err := json.MarshalIndent(v, "", "\t")
 	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	written := false
+	if len(opts.outDir) > 0 {
+		jsonPath, err := jsonPath(path, opts)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := writeFile(jsonPath, buf, opts.force); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		written = true
+	}
+	if opts.format == "pseudo" {
+		// The pseudo-code skeleton is printed regardless of JSON output files.
+		return []byte(render.Pseudo(g, prims)), nil
+	}
+	if written {
+		return nil, nil
+	}
+	return buf, nil
+}
+
+// funcDir returns the output directory of the derived sequence of graphs of
+// the given input file; i.e. "DIR/<src>/<func>", where the function name is the
+// name of the input file. Files of distinct input files are thereby kept apart,
+// also when restructured concurrently.
+//
+// The source name is determined as by jsonPath, but falls back to the name of
+// the parent directory of the input file.
+func funcDir(dir, path string, opts options) string {
+	srcName := opts.srcName
+	if len(srcName) == 0 {
+		srcName = strings.TrimSuffix(filepath.Base(filepath.Dir(path)), "_graphs")
+	}
+	return filepath.Join(dir, srcName, pathutil.FileName(path))
+}
+
+// jsonPath returns the path of the JSON file of the primitives of the given
+// input file, as read by ll2go; i.e. "DIR/<src>_graphs/<func>.json", where the
+// function name is the name of the input file.
+func jsonPath(path string, opts options) (string, error) {
+	srcName := opts.srcName
+	if len(srcName) == 0 {
+		dir := filepath.Base(filepath.Dir(path))
+		if !strings.HasSuffix(dir, "_graphs") {
+			return "", errors.Errorf("unable to determine source name of %q; expected parent directory <src>_graphs, use -src to specify", path)
+		}
+		srcName = strings.TrimSuffix(dir, "_graphs")
+	}
+	graphsDir := fmt.Sprintf("%s_graphs", srcName)
+	jsonName := pathutil.FileName(path) + ".json"
+	return filepath.Join(opts.outDir, graphsDir, jsonName), nil
+}
+
+// writeFile writes the given output to the specified file, terminated by a new
+// line. Existing files are only overwritten if force is set.
+func writeFile(path string, buf []byte, force bool) error {
+	if !force && osutil.Exists(path) {
+		return errors.Errorf("output file %q already exists; use -force to overwrite", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
 		return errors.WithStack(err)
 	}
-	fmt.Println(string(buf))
 	return nil
 }
+
+// derivedGraphs records the derived sequence of graphs, G^1...G^n, during
+// control flow analysis.
+type derivedGraphs struct {
+	interval.NopObserver
+	// Derived sequence of graphs.
+	gs []*cfg.Graph
+}
+
+// DerivedGraph records the derived graph G^i.
+func (d *derivedGraphs) DerivedGraph(i int, g *cfg.Graph) {
+	d.gs = append(d.gs, g)
+}
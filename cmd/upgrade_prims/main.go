@@ -0,0 +1,84 @@
+// The upgrade_prims tool upgrades JSON files of control flow primitives to the
+// current version of the JSON format.
+//
+// Files of the legacy sequential primitive format of decomp and of the
+// unversioned format of cfa are converted in place.
+//
+// Usage:
+//
+//    upgrade_prims [OPTION]... FILE.json...
+//
+// Flags:
+//
+//    -n    validate files without rewriting them
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/mewmew/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+func usage() {
+	const use = `
+Upgrade JSON files of control flow primitives to the current version.
+
+Usage:
+
+	upgrade_prims [OPTION]... FILE.json...
+
+Flags:
+`
+	fmt.Fprintln(os.Stderr, use[1:])
+	flag.PrintDefaults()
+}
+
+func main() {
+	// dryRun specifies whether to validate files without rewriting them.
+	var dryRun bool
+	flag.BoolVar(&dryRun, "n", false, "validate files without rewriting them")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	for _, jsonPath := range flag.Args() {
+		if err := upgrade(jsonPath, dryRun); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+}
+
+// upgrade upgrades the given JSON file of control flow primitives to the
+// current version. If dryRun is set, the file is only validated.
+func upgrade(jsonPath string, dryRun bool) error {
+	old, err := ioutil.ReadFile(jsonPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	prims, err := primitive.Decode(bytes.NewReader(old))
+	if err != nil {
+		return errors.Errorf("unable to decode primitives of %q; %v", jsonPath, err)
+	}
+	buf, err := json.MarshalIndent(prims, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if bytes.Equal(old, buf) || dryRun {
+		return nil
+	}
+	log.Printf("upgrading %q to version %d", jsonPath, primitive.Version)
+	if err := ioutil.WriteFile(jsonPath, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
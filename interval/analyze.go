@@ -1,10 +1,11 @@
 package interval
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
+	"time"
 
 	"github.com/graphism/exp/cfg"
 	"github.com/mewkiz/pkg/term"
@@ -14,29 +15,72 @@ import (
 	"gonum.org/v1/gonum/graph/path"
 )
 
-// TODO: Remove once the package has matured.
-const debug = false
+// warn represents a logger with the "interval:" prefix, which logs warnings to
+// standard error. Debug messages are logged by LogObserver.
+//
+// Note, log.Logger is safe for concurrent use.
+var warn = log.New(os.Stderr, term.RedBold("interval:")+" ", 0)
 
-var (
-	// dbg represents a logger with the "interval:" prefix, which logs debug
-	// messages to standard error.
-	dbg = log.New(os.Stderr, term.BlueBold("interval:")+" ", 0)
-	// warn represents a logger with the "interval:" prefix, which logs warnings
-	// to standard error.
-	warn = log.New(os.Stderr, term.RedBold("interval:")+" ", 0)
-)
+// Limits specifies the resource limits of control flow analysis. The zero
+// value of each limit indicates no limit.
+type Limits struct {
+	// Maximum number of nodes in the control flow graph.
+	MaxNodes int
+	// Maximum length of the derived sequence of graphs.
+	MaxDepth int
+	// Maximum wall time of the analysis.
+	Timeout time.Duration
+}
 
-// Analyze analyzes the given control flow graph using the interval method.
-func Analyze(g *cfg.Graph) *primitive.Primitives {
+// Analyze analyzes the given control flow graph using the interval method. The
+// observer obs, if non-nil, is notified of the decisions made during analysis.
+//
+// The analysis is aborted if the context is cancelled or if any of the given
+// resource limits is exceeded, in which case the primitives recovered so far
+// are returned and marked as truncated.
+//
+// Analyze records the analysis state in the nodes of g. It is safe to analyze
+// distinct control flow graphs concurrently, but g must not be accessed by
+// other goroutines during analysis.
+func Analyze(ctx context.Context, g *cfg.Graph, limits Limits, obs Observer) *primitive.Primitives {
+	if obs == nil {
+		obs = NopObserver{}
+	}
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
 	prims := primitive.NewPrimitives()
+	if err := analyze(ctx, g, prims, limits, obs); err != nil {
+		warn.Printf("control flow analysis of %q truncated; %v", g.DOTID(), err)
+		prims.Truncated = true
+	}
+	return prims
+}
+
+// analyze records the control flow primitives of the given control flow graph
+// in prims.
+func analyze(ctx context.Context, g *cfg.Graph, prims *primitive.Primitives, limits Limits, obs Observer) error {
+	if limits.MaxNodes > 0 {
+		if n := g.Nodes().Len(); n > limits.MaxNodes {
+			return errors.Errorf("number of nodes (%d) exceeds limit (%d)", n, limits.MaxNodes)
+		}
+	}
 	dom := path.Dominators(g.Entry(), g)
 	// Structure switch statements.
-	structSwitch(g, prims, dom)
+	if err := structSwitch(ctx, g, prims, dom, obs); err != nil {
+		return errors.WithStack(err)
+	}
 	// Structure loops.
-	structLoop(g, prims)
+	if err := structLoop(ctx, g, prims, limits.MaxDepth, obs); err != nil {
+		return errors.WithStack(err)
+	}
 	// Structure if-statements.
-	structIf(g, prims, dom)
-	return prims
+	if err := structIf(ctx, g, prims, dom, obs); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
 }
 
 // === [ DCC ] =================================================================
@@ -44,9 +88,12 @@ func Analyze(g *cfg.Graph) *primitive.Primitives {
 // --- [ structCase ] ----------------------------------------------------------
 
 // structSwitch structures switch statements in the given control flow graph.
-func structSwitch(g *cfg.Graph, prims *primitive.Primitives, dom path.DominatorTree) {
+func structSwitch(ctx context.Context, g *cfg.Graph, prims *primitive.Primitives, dom path.DominatorTree, obs Observer) error {
 	// Search for case nodes in reverse post-order.
 	for _, n := range cfg.SortByRevPost(graph.NodesOf(g.Nodes())) {
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
 		headSuccs := cfg.SortByRevPost(graph.NodesOf(g.From(n.ID())))
 		if len(headSuccs) > 2 {
 			// Switch header node.
@@ -95,10 +142,11 @@ func structSwitch(g *cfg.Graph, prims *primitive.Primitives, dom path.DominatorT
 			for _, n := range cfg.SortByRevPost(ns) {
 				prim.Nodes = append(prim.Nodes, n.DOTID())
 			}
-			//pretty.Println("switch:", prim)
+			obs.Switch(prim)
 			prims.Switches = append(prims.Switches, prim)
 		}
 	}
+	return nil
 }
 
 // --- [ tagNodesInCase  ] -----------------------------------------------------
@@ -140,42 +188,40 @@ func isSuccessor(g *cfg.Graph, n, head graph.Node) bool {
 
 // --- [ structLoops ] ---------------------------------------------------------
 
-// structLoop structures loops in the given control flow graph.
-func structLoop(g *cfg.Graph, prims *primitive.Primitives) {
-	// Note, the call to DerivedSeq initiates the reverse post-order number of
+// structLoop structures loops in the given control flow graph. The length of
+// the derived sequence of graphs is limited to maxDepth (unless 0).
+func structLoop(ctx context.Context, g *cfg.Graph, prims *primitive.Primitives, maxDepth int, obs Observer) error {
+	// Note, the call to derivedSeq initiates the reverse post-order number of
 	// each node.
 	// For all derived sequences G_i.
-	Gs, IIs := DerivedSeq(g)
-	// TODO: Remove when cfa has matured. Useful for debugging.
-	if debug {
-		for i, Gi := range Gs {
-			if err := os.MkdirAll("_derived_", 0755); err != nil {
-				log.Fatalf("%+v", errors.WithStack(err))
-			}
-			if err := ioutil.WriteFile(fmt.Sprintf("_derived_/G_%d.dot", i), []byte(Gi.String()), 0644); err != nil {
-				log.Fatalf("%+v", errors.WithStack(err))
-			}
-		}
+	Gs, IIs, err := derivedSeq(ctx, g, maxDepth)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, Gi := range Gs {
+		obs.DerivedGraph(i+1, Gi)
 	}
 	for i, Gi := range Gs {
 		// For all intervals I_i of G_i.
 		dom := path.Dominators(Gi.Entry(), Gi)
 		for j, I := range IIs[i] {
+			if err := ctx.Err(); err != nil {
+				return errors.WithStack(err)
+			}
 			// Record interval information.
-			intervalName := fmt.Sprintf("G%d_I%d", i, j+1)
+			//
+			// Note, the interval name matches the name assigned by DerivedSeq to
+			// the collapsed node of the interval in the next derived graph.
+			name := intervalName(i+1, j)
 			intervalNodes := nodeNames(cfg.SortByRevPost(graph.NodesOf(I.Nodes())))
-			dbg.Printf("%v: %v\n", intervalName, intervalNodes)
-			if prev, ok := prims.Intervals[intervalName]; ok {
-				panic(fmt.Errorf("interval with name %q already present; prev nodes %v, new nodes %v", intervalName, prev, intervalNodes))
+			obs.Interval(name, intervalNodes)
+			if prev, ok := prims.Intervals[name]; ok {
+				panic(fmt.Errorf("interval with name %q already present; prev nodes %v, new nodes %v", name, prev, intervalNodes))
 			}
-			prims.Intervals[intervalName] = intervalNodes
+			prims.Intervals[name] = intervalNodes
 			// Find greatest enclosing back edge (if any).
 			var latch *cfg.Node
 			for _, pred := range cfg.SortByRevPost(graph.NodesOf(Gi.To(I.h.ID()))) {
-				// TODO: Remove when cfa has matured. Useful for debugging.
-				//dbg.Printf("pred of %v: %v\n", I.h.DOTID(), pred.DOTID())
-				//dbg.Printf("I.Has(%v)=%v\n", pred.DOTID(), I.Has(pred))
-				//dbg.Printf("isBackEdge(%v, %v)=%v\n", pred.DOTID(), I.h.DOTID(), isBackEdge(pred, I.h))
 				if I.Has(pred) && isBackEdge(pred, I.h) {
 					if latch == nil {
 						latch = pred
@@ -188,10 +234,9 @@ func structLoop(g *cfg.Graph, prims *primitive.Primitives) {
 			if latch != nil {
 				// Check that the latching node is at the same nesting level of case
 				// statement (if any).
-				dbg.Println("located latch node:", latch.DOTID())
 				if latch.SwitchHead != nil && latch.SwitchHead == I.h.SwitchHead {
-					//fmt.Println("   SKIP: latch switch head:", latch.SwitchHead.DOTID())
-					//fmt.Println("   SKIP: interval head switch head:", I.h.SwitchHead.DOTID())
+					reason := fmt.Sprintf("latch node %v and header node share switch head %v", latch.DOTID(), latch.SwitchHead.DOTID())
+					obs.Reject("loop", I.h.DOTID(), reason)
 					continue
 				}
 				// Check that the node doesn't belong to another loop.
@@ -201,15 +246,22 @@ func structLoop(g *cfg.Graph, prims *primitive.Primitives) {
 					if !ok {
 						// IT is a loop but with multiple exists; structure using
 						// goto.
+						reason := fmt.Sprintf("latch node %v has %d successors", latch.DOTID(), Gi.From(latch.ID()).Len())
+						obs.Reject("loop", I.h.DOTID(), reason)
 						continue
 					}
 					// Record loop information.
+					obs.Loop(loop)
 					prims.Loops = append(prims.Loops, loop)
 					latch.IsLatch = true // TODO: Remove if not needed.
+				} else {
+					reason := fmt.Sprintf("latch node %v already belongs to loop headed by %v", latch.DOTID(), latch.LoopHead.DOTID())
+					obs.Reject("loop", I.h.DOTID(), reason)
 				}
 			}
 		}
 	}
+	return nil
 }
 
 // --- [ findNodesInLoop ] -----------------------------------------------------
@@ -355,7 +407,7 @@ func isBackEdge(p, s *cfg.Node) bool {
 // structIf structures if-statements in the given control flow graph.
 //
 // Pre-condition: the nodes of the graph are numbered in reverse post-order.
-func structIf(g *cfg.Graph, prims *primitive.Primitives, dom path.DominatorTree) {
+func structIf(ctx context.Context, g *cfg.Graph, prims *primitive.Primitives, dom path.DominatorTree, obs Observer) error {
 	// TODO: Ensure that the header and latch nodes of loops are correctly
 	// labelled, so they are not considered if-statements. It is possible, quite
 	// likely even, that the current code updates n.LoopHeader for the inveral
@@ -367,6 +419,9 @@ func structIf(g *cfg.Graph, prims *primitive.Primitives, dom path.DominatorTree)
 
 	// Reverse reverse post-order (from Figure 13, 1993)
 	for _, n := range cfg.SortByPost(graph.NodesOf(g.Nodes())) {
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
 		// TODO: Check if !(loop header) should be determined with
 		//    n.LoopHead != n
 		// rather than
@@ -377,9 +432,9 @@ func structIf(g *cfg.Graph, prims *primitive.Primitives, dom path.DominatorTree)
 			// possible follow node.
 			var follow *cfg.Node
 			followInEdges := 0
-			// find all nodes that have this node as immediate dominator.
-			for _, m := range dom.DominatedBy(n) {
-				mm := node(m)
+			// find all nodes that have this node as immediate dominator; in
+			// reverse post-order, so that ties are resolved deterministically.
+			for _, mm := range cfg.SortByRevPost(dom.DominatedBy(n)) {
 				nInEdges := g.To(mm.ID()).Len()
 				// TODO: calculate on the fly instead of relying on isBackEdge calculation.
 				nBackEdges := mm.NBackEdges
@@ -389,26 +444,36 @@ func structIf(g *cfg.Graph, prims *primitive.Primitives, dom path.DominatorTree)
 				}
 			}
 			if follow != nil && followInEdges > 1 {
-				dbg.Printf("follow of %v: %v\n", n.DOTID(), follow.DOTID())
 				prim := &primitive.If{
 					Cond:   n.DOTID(),
 					Follow: follow.DOTID(),
 				}
 				n.IfFollow = follow
-				// Assign the follow node to all unresolved nodes.
+				// Assign the follow node to all unresolved nodes, in reverse
+				// post-order for deterministic output.
+				var ms []graph.Node
 				for m := range unresolved {
+					ms = append(ms, m)
+				}
+				for _, m := range cfg.SortByRevPost(ms) {
 					m.IfFollow = follow
 					delete(unresolved, m)
 					// TODO: Figure out the purpose of unresolved. For what type of
 					// CFGs do they appear?
 					prim.Unresolved = append(prim.Unresolved, m.DOTID())
 				}
+				obs.If(prim)
 				prims.Ifs = append(prims.Ifs, prim)
 			} else {
+				obs.Reject("if", n.DOTID(), "no follow node with more than one forward in-edge")
 				unresolved[n] = true
 			}
+		} else if g.From(n.ID()).Len() == 2 {
+			reason := fmt.Sprintf("node belongs to loop headed by %v", n.LoopHead.DOTID())
+			obs.Reject("if", n.DOTID(), reason)
 		}
 	}
+	return nil
 }
 
 // ### [ Helper functions ] ####################################################
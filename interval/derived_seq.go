@@ -5,9 +5,11 @@
 package interval
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/graphism/exp/cfg"
+	"github.com/pkg/errors"
 	"gonum.org/v1/gonum/graph"
 )
 
@@ -17,15 +19,41 @@ import (
 // intervals of the given control flow graph G, and the associated unique sets
 // of intervals, 𝓘^1...𝓘^n.
 func DerivedSeq(g *cfg.Graph) ([]*cfg.Graph, [][]*Interval) {
+	// The background context is never cancelled and the length is unlimited.
+	Gs, IIs, _ := derivedSeq(context.Background(), g, 0)
+	return Gs, IIs
+}
+
+// intervalName returns the name of the node collapsed from the j-th interval
+// (0-indexed) of the derived graph G^i (1-indexed).
+//
+// Note, intervals of G^1 are named G1_I1...G1_In; prior to primitive version 1
+// they were recorded as G0_I1...G0_In by structLoop.
+func intervalName(i, j int) string {
+	return fmt.Sprintf("G%d_I%d", i, j+1)
+}
+
+// derivedSeq returns the derived sequence of graphs, G^1...G^n, based on the
+// intervals of the given control flow graph G, and the associated unique sets
+// of intervals, 𝓘^1...𝓘^n. The construction is aborted if the context is
+// cancelled, or if the length of the sequence exceeds maxLen (unless 0).
+func derivedSeq(ctx context.Context, g *cfg.Graph, maxLen int) ([]*cfg.Graph, [][]*Interval, error) {
 	// G^1 = G
 	g.SetDOTID("G1")
 	Gs := []*cfg.Graph{g}
 	// 𝓘^1 = intervals(G^1)
-	IIs := [][]*Interval{Intervals(g)}
+	Is, err := intervals(ctx, g)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	IIs := [][]*Interval{Is}
 	// i = 2
 	i := 1 // 0-indexed.
 	// repeat /* construction of G^i */
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
 		//    make each interval of G^{i-1} a node in G^i
 		GNew := Gs[i-1]
 		for j, I := range IIs[i-1] {
@@ -35,7 +63,7 @@ func DerivedSeq(g *cfg.Graph) ([]*cfg.Graph, [][]*Interval) {
 				n := Inodes.Node()
 				delNodes[dotID(n)] = true
 			}
-			newName := fmt.Sprintf("G%d_I%d", i, j+1)
+			newName := intervalName(i, j)
 
 			// The collapsed node n of an interval I(h) has the immediate
 			// predecessors of h not part of the interval.
@@ -64,14 +92,21 @@ func DerivedSeq(g *cfg.Graph) ([]*cfg.Graph, [][]*Interval) {
 		if GNew.Nodes().Len() == Gs[i-1].Nodes().Len() {
 			break
 		}
+		if maxLen > 0 && len(Gs) >= maxLen {
+			return nil, nil, errors.Errorf("length of derived sequence of graphs exceeds limit (%d)", maxLen)
+		}
 		Gs = append(Gs, GNew)
 		//    𝓘^i = intervals(G^i)
-		IIs = append(IIs, Intervals(Gs[i]))
+		Is, err := intervals(ctx, Gs[i])
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		IIs = append(IIs, Is)
 		// until
 		//    G^i == G^{i-1}
 		i++
 	}
-	return Gs, IIs
+	return Gs, IIs, nil
 }
 
 // ### [ Helper functions ] ####################################################
@@ -6,18 +6,28 @@ package interval
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sort"
 
 	"gonum.org/v1/gonum/graph/iterator"
 
 	"github.com/graphism/exp/cfg"
+	"github.com/pkg/errors"
 	"gonum.org/v1/gonum/graph"
 )
 
 // Intervals returns the unique set of intervals of the given control flow
 // graph.
 func Intervals(g *cfg.Graph) []*Interval {
+	// The background context is never cancelled.
+	Is, _ := intervals(context.Background(), g)
+	return Is
+}
+
+// intervals returns the unique set of intervals of the given control flow
+// graph. The search is aborted if the context is cancelled.
+func intervals(ctx context.Context, g *cfg.Graph) ([]*Interval, error) {
 	// Calculate reverse post-order of nodes.
 	cfg.InitDFSOrder(g)
 	// 𝓘 = {}
@@ -27,6 +37,9 @@ func Intervals(g *cfg.Graph) []*Interval {
 	H.push(node(g.Entry()))
 	// for (all unprocessed n ∈ H) do
 	for !H.empty() {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
 		n := H.pop()
 		// I(n) = {n}
 		I := newInterval(g, n)
@@ -68,7 +81,7 @@ func Intervals(g *cfg.Graph) []*Interval {
 		// 𝓘 = 𝓘 + I(n)
 		Is = append(Is, I)
 	}
-	return Is
+	return Is, nil
 }
 
 // TODO: consider changing from `nodes map[graph.Node]bool` to `nodes
@@ -86,6 +99,11 @@ type Interval struct {
 	nodes map[graph.Node]bool
 }
 
+// Head returns the header node of the interval.
+func (I *Interval) Head() *cfg.Node {
+	return I.h
+}
+
 // Has reports whether the node exists within the interval.
 func (I *Interval) Has(n graph.Node) bool {
 	return I.nodes[n]
@@ -1,11 +1,16 @@
 package interval
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/graphism/exp/cfg"
@@ -129,3 +134,187 @@ func TestDerivedSeq(t *testing.T) {
 		}
 	}
 }
+
+func TestDOTWriter(t *testing.T) {
+	golden := []struct {
+		path string
+		want []string
+	}{
+		{
+			path: "testdata/control_flow_analysis_figure_2.dot",
+			want: []string{
+				"testdata/control_flow_analysis_figure_2.dot.G1.golden",
+				"testdata/control_flow_analysis_figure_2.dot.G2.golden",
+				"testdata/control_flow_analysis_figure_2.dot.G3.golden",
+				"testdata/control_flow_analysis_figure_2.dot.G4.golden",
+			},
+		},
+	}
+	for _, gold := range golden {
+		in, err := cfg.ParseFile(gold.path)
+		if err != nil {
+			t.Errorf("%q; unable to parse file; %v", gold.path, err)
+			continue
+		}
+		dir, err := ioutil.TempDir("", "interval")
+		if err != nil {
+			t.Fatalf("unable to create temporary directory; %v", err)
+		}
+		defer os.RemoveAll(dir)
+		w := NewDOTWriter(dir)
+		Analyze(context.Background(), in, Limits{}, w)
+		if err := w.Err(); err != nil {
+			t.Errorf("%q; unable to write derived sequence of graphs; %v", gold.path, err)
+			continue
+		}
+		for i, wantPath := range gold.want {
+			buf, err := ioutil.ReadFile(wantPath)
+			if err != nil {
+				t.Errorf("%q; unable to read file; %v", gold.path, err)
+				continue
+			}
+			want := strings.TrimSpace(string(buf))
+			gotPath := filepath.Join(dir, fmt.Sprintf("G%d.dot", i+1))
+			buf, err = ioutil.ReadFile(gotPath)
+			if err != nil {
+				t.Errorf("%q; unable to read file; %v", gold.path, err)
+				continue
+			}
+			got := strings.TrimSpace(string(buf))
+			if got != want {
+				t.Errorf("%q; output mismatch; expected `%s`, got `%s`", gotPath, want, got)
+				continue
+			}
+		}
+	}
+}
+
+func TestAnalyzeLimits(t *testing.T) {
+	const path = "testdata/structuring_decompiled_graphs_figure_2.dot"
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	golden := []struct {
+		ctx    context.Context
+		limits Limits
+		want   bool
+	}{
+		{ctx: context.Background(), limits: Limits{}, want: false},
+		{ctx: context.Background(), limits: Limits{MaxNodes: 15}, want: false},
+		{ctx: context.Background(), limits: Limits{MaxNodes: 14}, want: true},
+		{ctx: context.Background(), limits: Limits{MaxDepth: 4}, want: false},
+		{ctx: context.Background(), limits: Limits{MaxDepth: 3}, want: true},
+		{ctx: cancelled, limits: Limits{}, want: true},
+	}
+	for i, gold := range golden {
+		in, err := cfg.ParseFile(path)
+		if err != nil {
+			t.Errorf("%q; unable to parse file; %v", path, err)
+			continue
+		}
+		prims := Analyze(gold.ctx, in, gold.limits, nil)
+		if prims.Truncated != gold.want {
+			t.Errorf("i=%d: truncated mismatch; expected %v, got %v", i, gold.want, prims.Truncated)
+		}
+	}
+}
+
+// TestAnalyzeConcurrent checks that distinct control flow graphs may be
+// analyzed concurrently. Run with -race.
+func TestAnalyzeConcurrent(t *testing.T) {
+	paths := []string{
+		"testdata/structuring_decompiled_graphs_figure_2.dot",
+		"testdata/control_flow_analysis_figure_2.dot",
+	}
+	analyze := func(path string) (string, error) {
+		in, err := cfg.ParseFile(path)
+		if err != nil {
+			return "", err
+		}
+		prims := Analyze(context.Background(), in, Limits{}, nil)
+		buf, err := json.Marshal(prims)
+		if err != nil {
+			return "", err
+		}
+		return string(buf), nil
+	}
+	// Analyze sequentially to get the expected output.
+	var want []string
+	for _, path := range paths {
+		s, err := analyze(path)
+		if err != nil {
+			t.Fatalf("%q; unable to analyze file; %v", path, err)
+		}
+		want = append(want, s)
+	}
+	const n = 8
+	got := make([]string, n*len(paths))
+	errs := make([]error, n*len(paths))
+	var wg sync.WaitGroup
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i], errs[i] = analyze(paths[i%len(paths)])
+		}(i)
+	}
+	wg.Wait()
+	for i := range got {
+		path := paths[i%len(paths)]
+		if errs[i] != nil {
+			t.Errorf("%q; unable to analyze file; %v", path, errs[i])
+			continue
+		}
+		if got[i] != want[i%len(paths)] {
+			t.Errorf("%q; output mismatch; expected `%s`, got `%s`", path, want[i%len(paths)], got[i])
+		}
+	}
+}
+
+// TestIntervalNames checks that the name of each recorded interval of G^i
+// matches the name of its collapsed node in the derived graph G^{i+1}, and that
+// each interval expands to nodes of the original control flow graph G^1.
+func TestIntervalNames(t *testing.T) {
+	paths := []string{
+		"testdata/control_flow_analysis_figure_2.dot",
+		"testdata/structuring_decompiled_graphs_figure_2.dot",
+	}
+	for _, path := range paths {
+		in, err := cfg.ParseFile(path)
+		if err != nil {
+			t.Errorf("%q; unable to parse file; %v", path, err)
+			continue
+		}
+		Gs, _ := DerivedSeq(in)
+		g, err := cfg.ParseFile(path)
+		if err != nil {
+			t.Errorf("%q; unable to parse file; %v", path, err)
+			continue
+		}
+		prims := Analyze(context.Background(), g, Limits{}, nil)
+		if len(prims.Intervals) == 0 {
+			t.Errorf("%q; no intervals recorded", path)
+			continue
+		}
+		for name := range prims.Intervals {
+			var i, j int
+			if _, err := fmt.Sscanf(name, "G%d_I%d", &i, &j); err != nil {
+				t.Errorf("%q; invalid interval name %q; %v", path, name, err)
+				continue
+			}
+			if i < 1 || i > len(Gs) {
+				t.Errorf("%q; interval %q refers to derived graph G%d; expected G1...G%d", path, name, i, len(Gs))
+				continue
+			}
+			if i < len(Gs) {
+				if _, ok := Gs[i].NodeWithName(name); !ok {
+					t.Errorf("%q; unable to locate collapsed node of interval %q in %v", path, name, Gs[i].DOTID())
+				}
+			}
+			for _, n := range prims.Expand(name) {
+				if _, ok := in.NodeWithName(n); !ok {
+					t.Errorf("%q; interval %q expands to node %q not in G1", path, name, n)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package interval
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewmew/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+// An Observer is notified of the decisions made during control flow analysis.
+// Observers are useful for tracing and debugging the structuring algorithms.
+type Observer interface {
+	// DerivedGraph is invoked for each graph G^i of the derived sequence of
+	// graphs, G^1...G^n, where i is 1-indexed.
+	DerivedGraph(i int, g *cfg.Graph)
+	// Interval is invoked for each interval located in a derived graph, where
+	// name is the name of the collapsed node and nodes are the nodes of the
+	// interval, in reverse post-order.
+	Interval(name string, nodes []string)
+	// Switch is invoked for each recovered switch statement.
+	Switch(prim *primitive.Switch)
+	// Loop is invoked for each recovered loop.
+	Loop(prim *primitive.Loop)
+	// If is invoked for each recovered if-statement.
+	If(prim *primitive.If)
+	// Reject is invoked when a candidate primitive of the given kind ("loop" or
+	// "if") headed by node is rejected, and specifies the reason why.
+	Reject(kind, node, reason string)
+}
+
+// NopObserver is an observer which ignores all notifications. It may be
+// embedded to implement only a subset of the Observer interface.
+type NopObserver struct{}
+
+// DerivedGraph implements the Observer interface.
+func (NopObserver) DerivedGraph(i int, g *cfg.Graph) {}
+
+// Interval implements the Observer interface.
+func (NopObserver) Interval(name string, nodes []string) {}
+
+// Switch implements the Observer interface.
+func (NopObserver) Switch(prim *primitive.Switch) {}
+
+// Loop implements the Observer interface.
+func (NopObserver) Loop(prim *primitive.Loop) {}
+
+// If implements the Observer interface.
+func (NopObserver) If(prim *primitive.If) {}
+
+// Reject implements the Observer interface.
+func (NopObserver) Reject(kind, node, reason string) {}
+
+// DOTWriter is an observer which writes each graph of the derived sequence of
+// graphs as a DOT file to the output directory (e.g. "dir/G1.dot").
+type DOTWriter struct {
+	NopObserver
+	// Output directory.
+	dir string
+	// First error encountered while writing DOT files.
+	err error
+}
+
+// NewDOTWriter returns a new observer which writes the derived sequence of
+// graphs as DOT files to the given output directory.
+func NewDOTWriter(dir string) *DOTWriter {
+	return &DOTWriter{dir: dir}
+}
+
+// DerivedGraph writes the derived graph G^i to the output directory.
+func (w *DOTWriter) DerivedGraph(i int, g *cfg.Graph) {
+	if w.err != nil {
+		return
+	}
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		w.err = errors.WithStack(err)
+		return
+	}
+	dotPath := filepath.Join(w.dir, fmt.Sprintf("G%d.dot", i))
+	if err := ioutil.WriteFile(dotPath, []byte(g.String()), 0644); err != nil {
+		w.err = errors.WithStack(err)
+	}
+}
+
+// Err returns the first error encountered while writing DOT files, if any.
+func (w *DOTWriter) Err() error {
+	return w.err
+}
+
+// MultiObserver returns an observer which notifies each of the given observers
+// in turn.
+func MultiObserver(obs ...Observer) Observer {
+	return multiObserver(obs)
+}
+
+// multiObserver is an observer which notifies a list of observers.
+type multiObserver []Observer
+
+// DerivedGraph implements the Observer interface.
+func (m multiObserver) DerivedGraph(i int, g *cfg.Graph) {
+	for _, obs := range m {
+		obs.DerivedGraph(i, g)
+	}
+}
+
+// Interval implements the Observer interface.
+func (m multiObserver) Interval(name string, nodes []string) {
+	for _, obs := range m {
+		obs.Interval(name, nodes)
+	}
+}
+
+// Switch implements the Observer interface.
+func (m multiObserver) Switch(prim *primitive.Switch) {
+	for _, obs := range m {
+		obs.Switch(prim)
+	}
+}
+
+// Loop implements the Observer interface.
+func (m multiObserver) Loop(prim *primitive.Loop) {
+	for _, obs := range m {
+		obs.Loop(prim)
+	}
+}
+
+// If implements the Observer interface.
+func (m multiObserver) If(prim *primitive.If) {
+	for _, obs := range m {
+		obs.If(prim)
+	}
+}
+
+// Reject implements the Observer interface.
+func (m multiObserver) Reject(kind, node, reason string) {
+	for _, obs := range m {
+		obs.Reject(kind, node, reason)
+	}
+}
+
+// LogObserver is an observer which logs the decisions made during control flow
+// analysis.
+type LogObserver struct {
+	// Logger of decisions.
+	l *log.Logger
+}
+
+// NewLogObserver returns a new observer which logs the decisions made during
+// control flow analysis to the given logger.
+func NewLogObserver(l *log.Logger) *LogObserver {
+	return &LogObserver{l: l}
+}
+
+// DerivedGraph implements the Observer interface.
+func (o *LogObserver) DerivedGraph(i int, g *cfg.Graph) {
+	o.l.Printf("derived graph %v: %d nodes", g.DOTID(), g.Nodes().Len())
+}
+
+// Interval implements the Observer interface.
+func (o *LogObserver) Interval(name string, nodes []string) {
+	o.l.Printf("interval %v: %v", name, nodes)
+}
+
+// Switch implements the Observer interface.
+func (o *LogObserver) Switch(prim *primitive.Switch) {
+	o.l.Printf("switch %v: follow %v, nodes %v", prim.Head, prim.Follow, prim.Nodes)
+}
+
+// Loop implements the Observer interface.
+func (o *LogObserver) Loop(prim *primitive.Loop) {
+	o.l.Printf("%v loop %v: latch %v, follow %v, nodes %v", prim.Type, prim.Head, prim.Latch, prim.Follow, prim.Nodes)
+}
+
+// If implements the Observer interface.
+func (o *LogObserver) If(prim *primitive.If) {
+	o.l.Printf("if %v: follow %v", prim.Cond, prim.Follow)
+}
+
+// Reject implements the Observer interface.
+func (o *LogObserver) Reject(kind, node, reason string) {
+	o.l.Printf("rejected %s %v; %s", kind, node, reason)
+}
@@ -0,0 +1,172 @@
+package primitive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/pkg/errors"
+)
+
+// Version is the current version of the JSON format of control flow
+// primitives, as described by the JSON Schema of schema.json.
+//
+// Version history:
+//
+//    0: unversioned format; without version and truncated fields. Intervals of
+//       the derived graph G^i are named G{i-1}_I{j}, rather than by the name
+//       G{i}_I{j} of their collapsed node in G^{i+1}.
+//    1: current format.
+//
+// Files of the legacy sequential primitive format of decomp (a JSON array of
+// primitives with "prim", "node" and "entry" fields) predate versioning, and
+// are upgraded by Decode.
+const Version = 1
+
+// Decode decodes the control flow primitives of a function from the given JSON
+// input. Unknown fields are reported as errors. Input of the unversioned and
+// legacy sequential formats is upgraded to the current version, renumbering the
+// intervals of the unversioned format.
+func Decode(r io.Reader) (*Primitives, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	buf = bytes.TrimSpace(buf)
+	if bytes.HasPrefix(buf, []byte("[")) {
+		// Legacy sequential format.
+		var seqs []*Sequential
+		if err := decodeStrict(buf, &seqs); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		prims, err := Upgrade(seqs)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return prims, nil
+	}
+	prims := NewPrimitives()
+	prims.Version = 0
+	if err := decodeStrict(buf, prims); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch prims.Version {
+	case 0:
+		intervals, err := renumberIntervals(prims.Intervals)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		prims.Intervals = intervals
+		prims.Version = Version
+	case Version:
+		// current version.
+	default:
+		return nil, errors.Errorf("unsupported version %d of control flow primitives; expected version <= %d", prims.Version, Version)
+	}
+	return prims, nil
+}
+
+// renumberIntervals renames the intervals of the unversioned format, G{i}_I{j},
+// to G{i+1}_I{j}, so that each interval name matches the name of its collapsed
+// node in the next derived graph. Other interval names are left as is.
+//
+// Note, only the interval names are renumbered, as the nodes of intervals and
+// primitives were named by their collapsed nodes also in the unversioned
+// format.
+func renumberIntervals(intervals map[string][]string) (map[string][]string, error) {
+	renamed := make(map[string][]string)
+	for name, nodes := range intervals {
+		newName := name
+		if m := intervalNameRegexp.FindStringSubmatch(name); m != nil {
+			i, err := strconv.Atoi(m[1])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			newName = fmt.Sprintf("G%d_I%s", i+1, m[2])
+		}
+		if _, ok := renamed[newName]; ok {
+			return nil, errors.Errorf("duplicate interval %q after renumbering interval %q", newName, name)
+		}
+		renamed[newName] = nodes
+	}
+	return renamed, nil
+}
+
+// intervalNameRegexp matches interval names of the form G{i}_I{j}.
+var intervalNameRegexp = regexp.MustCompile(`^G([0-9]+)_I([0-9]+)$`)
+
+// decodeStrict decodes the given JSON input into v, reporting unknown fields
+// and trailing data as errors.
+func decodeStrict(buf []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errors.WithStack(err)
+	}
+	if dec.More() {
+		return errors.New("invalid trailing data after control flow primitives")
+	}
+	return nil
+}
+
+// Upgrade converts the given primitives of the legacy sequential format into
+// control flow primitives of the current version. The nodes collapsed by each
+// sequential primitive, including its exit node, are recorded as an interval of
+// the entry node of the primitive.
+func Upgrade(seqs []*Sequential) (*Primitives, error) {
+	prims := NewPrimitives()
+	for _, seq := range seqs {
+		rs, ok := roles[seq.Prim]
+		if !ok {
+			return nil, errors.Errorf("unknown sequential primitive %q", seq.Prim)
+		}
+		if len(seq.Nodes) != len(rs) {
+			return nil, errors.Errorf("invalid number of nodes of %q primitive %q; expected %d, got %d", seq.Prim, seq.Entry, len(rs), len(seq.Nodes))
+		}
+		var nodes []string
+		for _, role := range rs {
+			name, ok := seq.Nodes[role]
+			if !ok {
+				return nil, errors.Errorf("unable to locate %s node of %q primitive %q", role, seq.Prim, seq.Entry)
+			}
+			nodes = append(nodes, name)
+		}
+		if _, ok := prims.Intervals[seq.Entry]; ok {
+			return nil, errors.Errorf("duplicate entry node %q of sequential primitives", seq.Entry)
+		}
+		prims.Intervals[seq.Entry] = nodes
+		exit := nodes[len(nodes)-1]
+		cond := seq.Nodes["cond"]
+		switch seq.Prim {
+		case "if", "if_else", "if_return":
+			prim := &If{
+				Cond:   cond,
+				Follow: exit,
+			}
+			prims.Ifs = append(prims.Ifs, prim)
+		case "pre_loop":
+			prim := &Loop{
+				Type:   cfg.LoopTypePreTest,
+				Head:   cond,
+				Latch:  seq.Nodes["body"],
+				Follow: exit,
+				Nodes:  []string{seq.Nodes["body"]},
+			}
+			prims.Loops = append(prims.Loops, prim)
+		case "post_loop":
+			prim := &Loop{
+				Type:   cfg.LoopTypePostTest,
+				Head:   cond,
+				Latch:  cond,
+				Follow: exit,
+			}
+			prims.Loops = append(prims.Loops, prim)
+		}
+	}
+	return prims, nil
+}
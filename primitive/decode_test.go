@@ -0,0 +1,134 @@
+package primitive
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/graphism/exp/cfg"
+)
+
+func TestDecode(t *testing.T) {
+	golden := []struct {
+		in   string
+		want *Primitives
+		err  string
+	}{
+		// Unversioned format.
+		{
+			in: `{"intervals": {"I1": ["A", "B"]}, "switches": null, "loops": null, "ifs": [{"cond": "A", "follow": "B", "unresolved": null}]}`,
+			want: &Primitives{
+				Version:   Version,
+				Intervals: map[string][]string{"I1": {"A", "B"}},
+				Ifs:       []*If{{Cond: "A", Follow: "B"}},
+			},
+		},
+		// Unversioned format with intervals of derived graphs.
+		{
+			in: `{"intervals": {"G0_I1": ["A", "B"], "G1_I1": ["G1_I1"]}, "switches": null, "loops": null, "ifs": null}`,
+			want: &Primitives{
+				Version:   Version,
+				Intervals: map[string][]string{"G1_I1": {"A", "B"}, "G2_I1": {"G1_I1"}},
+			},
+		},
+		// Current format.
+		{
+			in: `{"version": 1, "intervals": {}, "switches": null, "loops": null, "ifs": null, "truncated": true}`,
+			want: &Primitives{
+				Version:   Version,
+				Intervals: map[string][]string{},
+				Truncated: true,
+			},
+		},
+		// Legacy sequential format.
+		{
+			in: `[
+				{"prim": "pre_loop", "node": {"cond": "B", "body": "C", "exit": "D"}, "entry": "pre_loop_0"},
+				{"prim": "if", "node": {"cond": "A", "body": "E", "exit": "pre_loop_0"}, "entry": "if_0"},
+				{"prim": "seq", "node": {"entry": "F", "exit": "if_0"}, "entry": "seq_0"}
+			]`,
+			want: &Primitives{
+				Version: Version,
+				Intervals: map[string][]string{
+					"pre_loop_0": {"B", "C", "D"},
+					"if_0":       {"A", "E", "pre_loop_0"},
+					"seq_0":      {"F", "if_0"},
+				},
+				Loops: []*Loop{{Type: cfg.LoopTypePreTest, Head: "B", Latch: "C", Follow: "D", Nodes: []string{"C"}}},
+				Ifs:   []*If{{Cond: "A", Follow: "pre_loop_0"}},
+			},
+		},
+		// Unknown field.
+		{
+			in:  `{"version": 1, "intervals": {}, "switches": null, "loops": null, "ifs": null, "foo": 1}`,
+			err: `unknown field "foo"`,
+		},
+		// Unsupported version.
+		{
+			in:  `{"version": 2}`,
+			err: "unsupported version 2",
+		},
+		// Unknown sequential primitive.
+		{
+			in:  `[{"prim": "switch", "node": {}, "entry": "switch_0"}]`,
+			err: `unknown sequential primitive "switch"`,
+		},
+	}
+	for i, gold := range golden {
+		got, err := Decode(strings.NewReader(gold.in))
+		if len(gold.err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), gold.err) {
+				t.Errorf("i=%d: error mismatch; expected %q, got %v", i, gold.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("i=%d: unexpected error; %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, gold.want) {
+			t.Errorf("i=%d: primitives mismatch; expected %#v, got %#v", i, gold.want, got)
+		}
+	}
+}
+
+// TestDecodeUnversioned checks that the intervals of an unversioned file, as
+// output by restructure_interval prior to versioning, are renumbered.
+func TestDecodeUnversioned(t *testing.T) {
+	const path = "testdata/control_flow_analysis_figure_2_v0.json"
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%q; unable to open file; %v", path, err)
+	}
+	defer f.Close()
+	prims, err := Decode(f)
+	if err != nil {
+		t.Fatalf("%q; unable to decode file; %v", path, err)
+	}
+	if prims.Version != Version {
+		t.Errorf("%q; version mismatch; expected %d, got %d", path, Version, prims.Version)
+	}
+	want := map[string][]string{
+		"G1_I1": {"1"},
+		"G1_I2": {"2"},
+		"G1_I3": {"3", "5", "4", "6"},
+		"G1_I4": {"7", "8"},
+		"G2_I1": {"G1_I1"},
+		"G2_I2": {"G1_I2", "G1_I3", "G1_I4"},
+		"G3_I1": {"G2_I1", "G2_I2"},
+		"G4_I1": {"G3_I1"},
+	}
+	if !reflect.DeepEqual(prims.Intervals, want) {
+		t.Errorf("%q; intervals mismatch; expected %v, got %v", path, want, prims.Intervals)
+	}
+	// The single node of the last derived graph expands to the nodes of the
+	// original control flow graph.
+	got := prims.Expand("G4_I1")
+	sort.Strings(got)
+	wantNodes := []string{"1", "2", "3", "4", "5", "6", "7", "8"}
+	if !reflect.DeepEqual(got, wantNodes) {
+		t.Errorf("%q; expanded nodes mismatch; expected %v, got %v", path, wantNodes, got)
+	}
+}
@@ -0,0 +1,129 @@
+package primitive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Change is a semantic difference between two sets of control flow
+// primitives of a function.
+type Change struct {
+	// Kind of primitive ("loop", "switch" or "if").
+	Kind string
+	// Nodes of the primitive in the original control flow graph, formatted as
+	// "{A B C}"; i.e. the nodes of loops and switch statements, and the
+	// condition node of if-statements. Primitives are identified by kind and
+	// nodes.
+	Nodes string
+	// Description of the primitive before the change; or empty if added.
+	Old string
+	// Description of the primitive after the change; or empty if removed.
+	New string
+}
+
+// String returns a string representation of the change.
+func (c *Change) String() string {
+	switch {
+	case len(c.Old) == 0:
+		return fmt.Sprintf("added %s %s: %s", c.Kind, c.Nodes, c.New)
+	case len(c.New) == 0:
+		return fmt.Sprintf("removed %s %s: %s", c.Kind, c.Nodes, c.Old)
+	default:
+		return fmt.Sprintf("changed %s %s: %s -> %s", c.Kind, c.Nodes, c.Old, c.New)
+	}
+}
+
+// Diff returns the semantic differences between the control flow primitives a
+// and b of a function. Nodes of derived graphs are expanded into the
+// corresponding nodes of the original control flow graph, and the order of
+// primitives and nodes is ignored. Changes are sorted by kind and nodes.
+func Diff(a, b *Primitives) []*Change {
+	var changes []*Change
+	for _, kind := range []string{"loop", "switch", "if"} {
+		as, bs := a.describe(kind), b.describe(kind)
+		for nodes, oldDesc := range as {
+			if newDesc, ok := bs[nodes]; !ok {
+				changes = append(changes, &Change{Kind: kind, Nodes: nodes, Old: oldDesc})
+			} else if oldDesc != newDesc {
+				changes = append(changes, &Change{Kind: kind, Nodes: nodes, Old: oldDesc, New: newDesc})
+			}
+		}
+		for nodes, newDesc := range bs {
+			if _, ok := as[nodes]; !ok {
+				changes = append(changes, &Change{Kind: kind, Nodes: nodes, New: newDesc})
+			}
+		}
+	}
+	kinds := map[string]int{"loop": 0, "switch": 1, "if": 2}
+	less := func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return kinds[changes[i].Kind] < kinds[changes[j].Kind]
+		}
+		return changes[i].Nodes < changes[j].Nodes
+	}
+	sort.Slice(changes, less)
+	return changes
+}
+
+// describe returns a map from the node set of each primitive of the given kind
+// to its canonical description. The descriptions of distinct primitives with
+// the same node set are sorted and joined by "; ".
+func (prims *Primitives) describe(kind string) map[string]string {
+	descs := make(map[string][]string)
+	switch kind {
+	case "loop":
+		for _, prim := range prims.Loops {
+			nodes := prims.expandSet(append([]string{prim.Head}, prim.Nodes...)...)
+			desc := fmt.Sprintf("type %v, head %s, latch %s, follow %s", prim.Type, prims.expandFirst(prim.Head), prims.expandSet(prim.Latch), prims.expandFirst(prim.Follow))
+			descs[nodes] = append(descs[nodes], desc)
+		}
+	case "switch":
+		for _, prim := range prims.Switches {
+			nodes := prims.expandSet(append([]string{prim.Head}, prim.Nodes...)...)
+			desc := fmt.Sprintf("head %s, follow %s", prims.expandFirst(prim.Head), prims.expandFirst(prim.Follow))
+			descs[nodes] = append(descs[nodes], desc)
+		}
+	case "if":
+		for _, prim := range prims.Ifs {
+			nodes := prims.expandSet(prim.Cond)
+			desc := fmt.Sprintf("follow %s, unresolved %s", prims.expandFirst(prim.Follow), prims.expandSet(prim.Unresolved...))
+			descs[nodes] = append(descs[nodes], desc)
+		}
+	}
+	m := make(map[string]string)
+	for nodes, ds := range descs {
+		sort.Strings(ds)
+		m[nodes] = strings.Join(ds, "; ")
+	}
+	return m
+}
+
+// expandFirst returns the first node of the expansion of the given node name;
+// or "-" if empty.
+func (prims *Primitives) expandFirst(name string) string {
+	if len(name) == 0 {
+		return "-"
+	}
+	return prims.Expand(name)[0]
+}
+
+// expandSet returns the sorted set of nodes of the expansion of the given node
+// names, formatted as "{A B C}".
+func (prims *Primitives) expandSet(names ...string) string {
+	set := make(map[string]bool)
+	for _, name := range names {
+		if len(name) == 0 {
+			continue
+		}
+		for _, n := range prims.Expand(name) {
+			set[n] = true
+		}
+	}
+	var ns []string
+	for n := range set {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	return "{" + strings.Join(ns, " ") + "}"
+}
@@ -0,0 +1,71 @@
+package primitive
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/graphism/exp/cfg"
+)
+
+func TestDiff(t *testing.T) {
+	a := &Primitives{
+		Intervals: map[string][]string{"G1_I2": {"B", "C"}},
+		Loops:     []*Loop{{Type: cfg.LoopTypePreTest, Head: "B", Latch: "C", Follow: "D", Nodes: []string{"C"}}},
+		Ifs: []*If{
+			{Cond: "A", Follow: "D"},
+			{Cond: "E", Follow: "F"},
+		},
+	}
+	// Same primitives as a, in a different order and using collapsed nodes,
+	// with one if-statement changed, one removed and one added.
+	b := &Primitives{
+		Intervals: map[string][]string{"G1_I2": {"B", "C"}},
+		Loops:     []*Loop{{Type: cfg.LoopTypePreTest, Head: "G1_I2", Latch: "C", Follow: "D", Nodes: []string{"C"}}},
+		Ifs: []*If{
+			{Cond: "X", Follow: "F"},
+			{Cond: "A", Follow: "E"},
+		},
+	}
+	// Changes of the same kind are sorted by nodes.
+	want := []string{
+		"changed if {A}: follow D, unresolved {} -> follow E, unresolved {}",
+		"removed if {E}: follow F, unresolved {}",
+		"added if {X}: follow F, unresolved {}",
+	}
+	var got []string
+	for _, change := range Diff(a, b) {
+		got = append(got, change.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes mismatch; expected %q, got %q", want, got)
+	}
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes of identical primitives, got %v", changes)
+	}
+}
+
+// TestDiffSharedHead checks that distinct primitives with the same header node
+// are compared separately.
+func TestDiffSharedHead(t *testing.T) {
+	a := &Primitives{
+		Loops: []*Loop{
+			{Type: cfg.LoopTypePostTest, Head: "B", Latch: "C", Follow: "D", Nodes: []string{"B", "C"}},
+			{Type: cfg.LoopTypePostTest, Head: "B", Latch: "D", Follow: "E", Nodes: []string{"B", "C", "D"}},
+		},
+	}
+	b := &Primitives{
+		Loops: []*Loop{
+			{Type: cfg.LoopTypePostTest, Head: "B", Latch: "D", Follow: "E", Nodes: []string{"B", "C", "D"}},
+		},
+	}
+	want := []string{
+		"removed loop {B C}: type post_test, head B, latch {C}, follow D",
+	}
+	var got []string
+	for _, change := range Diff(a, b) {
+		got = append(got, change.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes mismatch; expected %q, got %q", want, got)
+	}
+}
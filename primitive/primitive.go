@@ -4,6 +4,8 @@ import "github.com/graphism/exp/cfg"
 
 // Primitives records the control flow primitives of a function.
 type Primitives struct {
+	// Version of the JSON format of the control flow primitives.
+	Version int `json:"version"`
 	// map from collapsed node name to the nodes of the corresponding interval.
 	Intervals map[string][]string `json:"intervals"`
 	// Switch-statements.
@@ -13,16 +15,35 @@ type Primitives struct {
 	Loops []*Loop `json:"loops"`
 	// If-statements.
 	Ifs []*If `json:"ifs"`
+	// Truncated specifies whether control flow analysis was aborted before
+	// completion, in which case the primitives are incomplete.
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // NewPrimitives returns a new record for the control flow primitives of a
 // function.
 func NewPrimitives() *Primitives {
 	return &Primitives{
+		Version:   Version,
 		Intervals: make(map[string][]string),
 	}
 }
 
+// Expand returns the names of the nodes in the original control flow graph
+// represented by the given node name. Collapsed nodes of derived graphs are
+// recursively expanded into the nodes of their corresponding intervals.
+func (prims *Primitives) Expand(name string) []string {
+	nodes, ok := prims.Intervals[name]
+	if !ok {
+		return []string{name}
+	}
+	var names []string
+	for _, n := range nodes {
+		names = append(names, prims.Expand(n)...)
+	}
+	return names
+}
+
 // A Switch is an n-way conditional control flow primitive.
 type Switch struct {
 	// Header node of the switch statement.
@@ -0,0 +1,156 @@
+package primitive
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/graph"
+)
+
+// Reduce collapses the primitives recovered from the given control flow graph
+// into single nodes, innermost primitives first, followed by sequences of nodes.
+// The residual graph is returned, together with a mapping from node names of the
+// residual graph to the names of the corresponding nodes in g.
+//
+// A residual graph of a single node indicates that the control flow graph was
+// fully structured; otherwise, the residual graph contains the unstructured
+// control flow. Primitives overlapping already collapsed primitives are not
+// collapsed. The control flow graph g is left unmodified.
+func (prims *Primitives) Reduce(g *cfg.Graph) (*cfg.Graph, map[string][]string, error) {
+	// Map from node name in the residual graph to the names of the nodes in g.
+	mapping := make(map[string][]string)
+	// Map from node name in g to node name in the residual graph.
+	cur := make(map[string]string)
+	for _, n := range graph.NodesOf(g.Nodes()) {
+		name := label(n)
+		mapping[name] = []string{name}
+		cur[name] = name
+	}
+	h := g
+	// Collapsed nodes of the residual graph. Self-loops of collapsed nodes are
+	// part of the collapsed primitive.
+	collapsed := make(map[string]bool)
+	// collapse collapses the given nodes of the residual graph into a single
+	// node with the given name.
+	collapse := func(delNodes map[string]bool, name string) {
+		var names []string
+		for n := range delNodes {
+			names = append(names, mapping[n]...)
+			delete(mapping, n)
+			delete(collapsed, n)
+		}
+		for _, n := range names {
+			cur[n] = name
+		}
+		mapping[name] = names
+		collapsed[name] = true
+		h = cfg.Merge(h, delNodes, name)
+	}
+	// uniqueName returns a node name based on the given name, which is not yet
+	// present in the residual graph.
+	uniqueName := func(base string) string {
+		name := base
+		for i := 1; ; i++ {
+			if _, ok := mapping[name]; !ok {
+				return name
+			}
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+	}
+
+	// Collapse primitives, starting with the innermost.
+	regions := prims.Regions(g)
+	less := func(i, j int) bool {
+		return len(regions[i].Nodes) < len(regions[j].Nodes)
+	}
+	sort.SliceStable(regions, less)
+	for _, r := range regions {
+		delNodes := make(map[string]bool)
+		for n := range r.Nodes {
+			c, ok := cur[n]
+			if !ok {
+				return nil, nil, errors.Errorf("unable to locate node %q of %s primitive %q", n, r.Kind, r.Head)
+			}
+			delNodes[c] = true
+		}
+		if len(delNodes) == 0 || !nested(r, delNodes, mapping) {
+			continue
+		}
+		collapse(delNodes, uniqueName(fmt.Sprintf("%s_%s", r.Kind, r.Head)))
+	}
+
+	// Collapse sequences of nodes.
+	for {
+		var names []string
+		for name := range mapping {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var delNodes map[string]bool
+		for _, name := range names {
+			n, ok := h.NodeWithName(name)
+			if !ok {
+				return nil, nil, errors.Errorf("unable to locate node %q in residual graph", name)
+			}
+			succs := neighbours(h.From(n.ID()), n, collapsed)
+			if len(succs) != 1 || succs[0].ID() == n.ID() || succs[0].ID() == h.Entry().ID() {
+				continue
+			}
+			if len(neighbours(h.To(succs[0].ID()), succs[0], collapsed)) != 1 {
+				continue
+			}
+			delNodes = map[string]bool{name: true, label(succs[0]): true}
+			break
+		}
+		if delNodes == nil {
+			break
+		}
+		collapse(delNodes, uniqueName("seq"))
+	}
+
+	// Order the nodes of each collapsed node in reverse post-order of g.
+	for name, names := range mapping {
+		var ns []graph.Node
+		for _, n := range names {
+			node, ok := g.NodeWithName(n)
+			if !ok {
+				return nil, nil, errors.Errorf("unable to locate node %q", n)
+			}
+			ns = append(ns, node)
+		}
+		mapping[name] = nil
+		for _, n := range cfg.SortByRevPost(ns) {
+			mapping[name] = append(mapping[name], n.DOTID())
+		}
+	}
+	return h, mapping, nil
+}
+
+// nested reports whether the given nodes of the residual graph, which cover the
+// region r, are fully contained within r; i.e. whether the primitive of r
+// encloses all previously collapsed primitives it overlaps.
+func nested(r *Region, delNodes map[string]bool, mapping map[string][]string) bool {
+	for c := range delNodes {
+		for _, n := range mapping[c] {
+			if !r.Nodes[n] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// neighbours returns the given neighbours of the node n in the residual graph,
+// excluding self-loops of collapsed nodes.
+func neighbours(ns graph.Nodes, n graph.Node, collapsed map[string]bool) []graph.Node {
+	var nodes []graph.Node
+	for _, m := range graph.NodesOf(ns) {
+		if m.ID() == n.ID() && collapsed[label(n)] {
+			continue
+		}
+		nodes = append(nodes, m)
+	}
+	return nodes
+}
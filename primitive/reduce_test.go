@@ -0,0 +1,104 @@
+package primitive
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/graphism/exp/cfg"
+)
+
+func TestReduce(t *testing.T) {
+	golden := []struct {
+		in    string
+		prims *Primitives
+		// Mapping from residual node to sorted nodes of the original graph.
+		want map[string][]string
+		// Edges of the residual graph, as pairs of node names.
+		edges [][2]string
+	}{
+		// Fully structured; pre-test loop containing an if-statement.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B
+				B -> C [label=true]
+				B -> F [label=false]
+				C -> D [label=true]
+				C -> E [label=false]
+				D -> E
+				E -> B
+			}`,
+			prims: &Primitives{
+				Intervals: map[string][]string{"G1_I2": {"B", "C", "D", "E"}},
+				Loops:     []*Loop{{Type: cfg.LoopTypePreTest, Head: "G1_I2", Latch: "E", Follow: "F", Nodes: []string{"C", "D", "E"}}},
+				Ifs:       []*If{{Cond: "C", Follow: "E"}},
+			},
+			want: map[string][]string{
+				"seq_1": {"A", "B", "C", "D", "E", "F"},
+			},
+		},
+		// Unstructured; irreducible loop entered from both B and C, the edges of
+		// which are kept as goto-statements.
+		{
+			in: `digraph {
+				E [label=entry]
+				E -> A
+				A -> B [label=true]
+				A -> C [label=false]
+				B -> C
+				C -> B [label=true]
+				C -> D [label=false]
+			}`,
+			prims: &Primitives{},
+			want: map[string][]string{
+				"seq": {"A", "E"},
+				"B":   {"B"},
+				"C":   {"C"},
+				"D":   {"D"},
+			},
+			edges: [][2]string{{"seq", "B"}, {"seq", "C"}, {"B", "C"}, {"C", "B"}, {"C", "D"}},
+		},
+	}
+	for i, gold := range golden {
+		g, err := cfg.ParseBytes([]byte(gold.in))
+		if err != nil {
+			t.Errorf("i=%d: unable to parse graph; %v", i, err)
+			continue
+		}
+		n := g.Nodes().Len()
+		h, mapping, err := gold.prims.Reduce(g)
+		if err != nil {
+			t.Errorf("i=%d: unable to reduce primitives; %v", i, err)
+			continue
+		}
+		for _, names := range mapping {
+			sort.Strings(names)
+		}
+		if !reflect.DeepEqual(mapping, gold.want) {
+			t.Errorf("i=%d: mapping mismatch; expected %v, got %v", i, gold.want, mapping)
+		}
+		if got, want := h.Nodes().Len(), len(gold.want); got != want {
+			t.Errorf("i=%d: number of residual nodes mismatch; expected %d, got %d", i, want, got)
+		}
+		for _, edge := range gold.edges {
+			from, ok := h.NodeWithName(edge[0])
+			if !ok {
+				t.Errorf("i=%d: unable to locate node %q in residual graph", i, edge[0])
+				continue
+			}
+			to, ok := h.NodeWithName(edge[1])
+			if !ok {
+				t.Errorf("i=%d: unable to locate node %q in residual graph", i, edge[1])
+				continue
+			}
+			if !h.HasEdgeFromTo(from.ID(), to.ID()) {
+				t.Errorf("i=%d: unable to locate edge %v -> %v in residual graph", i, edge[0], edge[1])
+			}
+		}
+		// The original control flow graph is left unmodified.
+		if got := g.Nodes().Len(); got != n {
+			t.Errorf("i=%d: number of nodes of original graph mismatch; expected %d, got %d", i, n, got)
+		}
+	}
+}
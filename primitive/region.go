@@ -0,0 +1,159 @@
+package primitive
+
+import (
+	"github.com/graphism/exp/cfg"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/path"
+)
+
+// A Region is the set of nodes in the original control flow graph which belong
+// to a recovered control flow primitive.
+type Region struct {
+	// Kind of primitive ("loop", "switch" or "if").
+	Kind string
+	// Header node of the primitive (i.e. loop header, switch header or if
+	// condition).
+	Head string
+	// Latch node of the primitive; or empty if not a loop.
+	Latch string
+	// Follow node of the primitive; or empty if not present. The follow node is
+	// not part of the region.
+	Follow string
+	// Nodes of the region.
+	Nodes map[string]bool
+}
+
+// Contains reports whether the region r contains all nodes of the region s.
+func (r *Region) Contains(s *Region) bool {
+	for n := range s.Nodes {
+		if !r.Nodes[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// Overlaps reports whether the regions r and s have at least one node in
+// common.
+func (r *Region) Overlaps(s *Region) bool {
+	for n := range s.Nodes {
+		if r.Nodes[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// LoopRegion returns the region of the given loop primitive. Nodes of derived
+// graphs are expanded into the corresponding nodes of the original control flow
+// graph g.
+func (prims *Primitives) LoopRegion(g *cfg.Graph, prim *Loop) *Region {
+	r := &Region{
+		Kind:  "loop",
+		Nodes: make(map[string]bool),
+	}
+	// The header of an interval is the first node of the interval.
+	r.Head = prims.Expand(prim.Head)[0]
+	if len(prim.Follow) > 0 {
+		r.Follow = prims.Expand(prim.Follow)[0]
+	}
+	for _, n := range prims.Expand(prim.Head) {
+		r.Nodes[n] = true
+	}
+	for _, n := range prim.Nodes {
+		for _, m := range prims.Expand(n) {
+			r.Nodes[m] = true
+		}
+	}
+	// The latch node is the node of the collapsed latch with a back edge to the
+	// header.
+	head, ok := g.NodeWithName(r.Head)
+	if !ok {
+		return r
+	}
+	for _, n := range prims.Expand(prim.Latch) {
+		latch, ok := g.NodeWithName(n)
+		if !ok {
+			continue
+		}
+		if g.HasEdgeFromTo(latch.ID(), head.ID()) {
+			r.Latch = n
+			break
+		}
+	}
+	return r
+}
+
+// SwitchRegion returns the region of the given switch primitive.
+func SwitchRegion(prim *Switch) *Region {
+	r := &Region{
+		Kind:   "switch",
+		Head:   prim.Head,
+		Follow: prim.Follow,
+		Nodes:  make(map[string]bool),
+	}
+	for _, n := range prim.Nodes {
+		r.Nodes[n] = true
+	}
+	return r
+}
+
+// IfRegion returns the region of the given if-statement primitive, which
+// contains the condition node and the nodes dominated by the condition node
+// which are reachable from the condition node without passing through the
+// follow node.
+func IfRegion(g *cfg.Graph, dom path.DominatorTree, prim *If) *Region {
+	r := &Region{
+		Kind:   "if",
+		Head:   prim.Cond,
+		Follow: prim.Follow,
+		Nodes:  make(map[string]bool),
+	}
+	cond, ok := g.NodeWithName(prim.Cond)
+	if !ok {
+		return r
+	}
+	r.Nodes[prim.Cond] = true
+	queue := []graph.Node{cond}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, succ := range graph.NodesOf(g.From(n.ID())) {
+			name := label(succ)
+			if r.Nodes[name] || name == prim.Follow || !dominates(dom, cond, succ) {
+				continue
+			}
+			r.Nodes[name] = true
+			queue = append(queue, succ)
+		}
+	}
+	return r
+}
+
+// Regions returns the regions of the primitives recovered from the given
+// control flow graph; loops first, followed by switch statements and
+// if-statements, each in the order of prims.
+func (prims *Primitives) Regions(g *cfg.Graph) []*Region {
+	dom := path.Dominators(g.Entry(), g)
+	var rs []*Region
+	for _, prim := range prims.Loops {
+		rs = append(rs, prims.LoopRegion(g, prim))
+	}
+	for _, prim := range prims.Switches {
+		rs = append(rs, SwitchRegion(prim))
+	}
+	for _, prim := range prims.Ifs {
+		rs = append(rs, IfRegion(g, dom, prim))
+	}
+	return rs
+}
+
+// dominates reports whether the node a dominates the node b.
+func dominates(dom path.DominatorTree, a, b graph.Node) bool {
+	for n := b; n != nil; n = dom.DominatorOf(n) {
+		if n.ID() == a.ID() {
+			return true
+		}
+	}
+	return false
+}
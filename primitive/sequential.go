@@ -0,0 +1,435 @@
+package primitive
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/path"
+)
+
+// Sequential is a control flow primitive of the sequential format of decomp,
+// which records primitives in the order they were collapsed into single nodes.
+type Sequential struct {
+	// Primitive name; "if", "if_else", "if_return", "pre_loop", "post_loop" or
+	// "seq".
+	Prim string `json:"prim"`
+	// Map from role (e.g. "cond", "body", "exit") to node name.
+	Nodes map[string]string `json:"node"`
+	// Name of the node the primitive was collapsed into.
+	Entry string `json:"entry"`
+}
+
+// Roles of the nodes of each sequential primitive, in order of execution. All
+// nodes of a primitive, including its exit node, are collapsed into the entry
+// node of the primitive.
+var roles = map[string][]string{
+	"if":        {"cond", "body", "exit"},
+	"if_else":   {"cond", "body_true", "body_false", "exit"},
+	"if_return": {"cond", "body", "exit"},
+	"pre_loop":  {"cond", "body", "exit"},
+	"post_loop": {"cond", "exit"},
+	"seq":       {"entry", "exit"},
+}
+
+// Linearize converts the control flow primitives recovered from the given
+// control flow graph into sequential primitives of decomp, in bottom-up
+// reduction order; i.e. the primitives are listed in the order they are
+// collapsed, innermost primitives first, followed by sequences of nodes.
+//
+// Each if-statement and loop is collapsed into the sequential primitive of
+// decomp matching the nodes of its region, with the follow node of the
+// primitive as exit node. Switch statements and endless loops have no
+// sequential counterpart, and are thus not collapsed; neither are primitives
+// containing unstructured control flow, nor the primitives enclosing them. The
+// sequential primitives reduce the control flow graph to a single node only if
+// it is fully structured.
+func (prims *Primitives) Linearize(g *cfg.Graph) ([]*Sequential, error) {
+	// Regions of the primitives, and the kinds of sequential primitives of
+	// each.
+	type seqRegion struct {
+		*Region
+		kinds map[string]bool
+	}
+	var rs []seqRegion
+	for _, prim := range prims.Loops {
+		r := seqRegion{Region: prims.LoopRegion(g, prim)}
+		switch prim.Type {
+		case cfg.LoopTypePreTest:
+			r.kinds = map[string]bool{"pre_loop": true}
+		case cfg.LoopTypePostTest:
+			r.kinds = map[string]bool{"post_loop": true}
+		}
+		rs = append(rs, r)
+	}
+	dom := path.Dominators(g.Entry(), g)
+	for _, prim := range prims.Ifs {
+		r := seqRegion{
+			Region: IfRegion(g, dom, prim),
+			kinds:  map[string]bool{"if": true, "if_else": true, "if_return": true},
+		}
+		rs = append(rs, r)
+	}
+	// Collapse primitives, starting with the innermost.
+	less := func(i, j int) bool {
+		return len(rs[i].Nodes) < len(rs[j].Nodes)
+	}
+	sort.SliceStable(rs, less)
+	s := newSeqGraph(g)
+	var seqs []*Sequential
+	for _, r := range rs {
+		for _, n := range append(sortedNames(r.Nodes), r.Follow) {
+			if _, ok := s.cur[n]; !ok && len(n) > 0 {
+				return nil, errors.Errorf("unable to locate node %q of %s primitive %q", n, r.Kind, r.Head)
+			}
+		}
+		if r.kinds == nil {
+			// No sequential counterpart.
+			continue
+		}
+		// in reports whether the given node of the reduced graph is part of the
+		// region; the node of the header may contain preceding nodes.
+		in := func(name string) bool {
+			if name == s.cur[r.Head] {
+				return true
+			}
+			for _, n := range s.orig[name] {
+				if !r.Nodes[n] {
+					return false
+				}
+			}
+			return true
+		}
+		// Collapse sequences of nodes within the region.
+		seqs = append(seqs, s.reduceSeqs(in)...)
+		seq := s.match(s.cur[r.Head], r.kinds)
+		if seq == nil {
+			continue
+		}
+		// Check that the matched nodes are part of the region, and that the exit
+		// node is the follow node of the primitive.
+		valid := true
+		for role, name := range seq.Nodes {
+			if role != "exit" && !in(name) {
+				valid = false
+			}
+		}
+		if len(r.Follow) > 0 && seq.Nodes["exit"] != s.cur[r.Follow] {
+			valid = false
+		}
+		if !valid {
+			continue
+		}
+		s.collapse(seq)
+		seqs = append(seqs, seq)
+	}
+	// Collapse sequences of nodes.
+	all := func(name string) bool { return true }
+	seqs = append(seqs, s.reduceSeqs(all)...)
+	return seqs, nil
+}
+
+// sortedNames returns the names of the given set of nodes in sorted order.
+func sortedNames(nodes map[string]bool) []string {
+	var names []string
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// seqGraph is a control flow graph which is reduced by collapsing sequential
+// primitives into single nodes.
+type seqGraph struct {
+	// Entry node name.
+	entry string
+	// Map from node name to successor names. The successors of 2-way
+	// conditional nodes are ordered by true target and false target, if
+	// labelled; otherwise, successors are ordered by name.
+	succs map[string][]string
+	// Map from node name to predecessor names.
+	preds map[string][]string
+	// Map from node name to the names of the nodes in the original control flow
+	// graph collapsed into the node.
+	orig map[string][]string
+	// Map from node name in the original control flow graph to the name of the
+	// node it is collapsed into.
+	cur map[string]string
+	// Number of collapsed nodes of each primitive kind, used for naming.
+	counts map[string]int
+}
+
+// newSeqGraph returns a new reducible copy of the given control flow graph.
+func newSeqGraph(g *cfg.Graph) *seqGraph {
+	s := &seqGraph{
+		entry:  label(g.Entry()),
+		succs:  make(map[string][]string),
+		preds:  make(map[string][]string),
+		orig:   make(map[string][]string),
+		cur:    make(map[string]string),
+		counts: make(map[string]int),
+	}
+	for _, n := range graph.NodesOf(g.Nodes()) {
+		name := label(n)
+		succs := graph.NodesOf(g.From(n.ID()))
+		sort.Slice(succs, func(i, j int) bool {
+			return label(succs[i]) < label(succs[j])
+		})
+		if len(succs) == 2 {
+			// Order by true and false target, if the edges are labelled.
+			t, f := g.TrueTarget(n), g.FalseTarget(n)
+			if t != nil && f != nil {
+				succs = []graph.Node{t, f}
+			}
+		}
+		s.succs[name] = nil
+		for _, succ := range succs {
+			s.succs[name] = append(s.succs[name], label(succ))
+			s.preds[label(succ)] = append(s.preds[label(succ)], name)
+		}
+		s.orig[name] = []string{name}
+		s.cur[name] = name
+	}
+	return s
+}
+
+// reduceSeqs collapses sequences of nodes for which in reports true, and
+// returns the corresponding sequential primitives in the order collapsed.
+func (s *seqGraph) reduceSeqs(in func(name string) bool) []*Sequential {
+	kinds := map[string]bool{"seq": true}
+	var seqs []*Sequential
+	for {
+		var seq *Sequential
+		for _, n := range s.postOrder() {
+			if !in(n) {
+				continue
+			}
+			if seq = s.match(n, kinds); seq != nil && in(seq.Nodes["exit"]) {
+				break
+			}
+			seq = nil
+		}
+		if seq == nil {
+			return seqs
+		}
+		s.collapse(seq)
+		seqs = append(seqs, seq)
+	}
+}
+
+// postOrder returns the nodes reachable from the entry node, in post-order.
+func (s *seqGraph) postOrder() []string {
+	var names []string
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		visited[name] = true
+		for _, succ := range s.succs[name] {
+			if !visited[succ] {
+				visit(succ)
+			}
+		}
+		names = append(names, name)
+	}
+	visit(s.entry)
+	return names
+}
+
+// match returns the sequential primitive with entry node n of the given kinds,
+// or nil if no primitive is located.
+func (s *seqGraph) match(n string, kinds map[string]bool) *Sequential {
+	succs := s.succs[n]
+	switch len(succs) {
+	case 1:
+		// seq
+		//
+		//    entry
+		//      ↓
+		//    exit
+		exit := succs[0]
+		if kinds["seq"] && exit != n && exit != s.entry && s.onlyPreds(exit, n) {
+			return s.newSeq("seq", "entry", n, "exit", exit)
+		}
+	case 2:
+		for i, body := range succs {
+			exit := succs[1-i]
+			if body == n {
+				// post_loop
+				//
+				//    cond ↺
+				//      ↓
+				//    exit
+				if kinds["post_loop"] && exit != n && exit != s.entry && s.onlyPreds(exit, n) {
+					return s.newSeq("post_loop", "cond", n, "exit", exit)
+				}
+				continue
+			}
+			if exit == n || exit == s.entry || body == s.entry || !s.onlyPreds(body, n) {
+				continue
+			}
+			bodySuccs := s.succs[body]
+			switch {
+			// if
+			//
+			//    cond
+			//    ↓  ↘
+			//    ↓  body
+			//    ↓  ↙
+			//    exit
+			case kinds["if"] && len(bodySuccs) == 1 && bodySuccs[0] == exit && s.onlyPreds(exit, n, body):
+				return s.newSeq("if", "cond", n, "body", body, "exit", exit)
+			// if_return
+			//
+			//    cond
+			//    ↓  ↘
+			//    ↓  body
+			//    ↓
+			//    exit
+			case kinds["if_return"] && len(bodySuccs) == 0 && s.onlyPreds(exit, n):
+				return s.newSeq("if_return", "cond", n, "body", body, "exit", exit)
+			// pre_loop
+			//
+			//    cond ⇄ body
+			//     ↓
+			//    exit
+			case kinds["pre_loop"] && len(bodySuccs) == 1 && bodySuccs[0] == n && s.onlyPreds(exit, n):
+				return s.newSeq("pre_loop", "cond", n, "body", body, "exit", exit)
+			}
+		}
+		// if_else
+		//
+		//         cond
+		//        ↙    ↘
+		//    body_true  body_false
+		//        ↘    ↙
+		//         exit
+		if !kinds["if_else"] {
+			return nil
+		}
+		bodyTrue, bodyFalse := succs[0], succs[1]
+		if bodyTrue == n || bodyFalse == n || bodyTrue == s.entry || bodyFalse == s.entry {
+			return nil
+		}
+		if !s.onlyPreds(bodyTrue, n) || !s.onlyPreds(bodyFalse, n) {
+			return nil
+		}
+		trueSuccs, falseSuccs := s.succs[bodyTrue], s.succs[bodyFalse]
+		if len(trueSuccs) != 1 || len(falseSuccs) != 1 || trueSuccs[0] != falseSuccs[0] {
+			return nil
+		}
+		exit := trueSuccs[0]
+		if exit == n || exit == s.entry || !s.onlyPreds(exit, bodyTrue, bodyFalse) {
+			return nil
+		}
+		return s.newSeq("if_else", "cond", n, "body_true", bodyTrue, "body_false", bodyFalse, "exit", exit)
+	}
+	return nil
+}
+
+// onlyPreds reports whether the predecessors of the given node are exactly the
+// specified nodes.
+func (s *seqGraph) onlyPreds(name string, preds ...string) bool {
+	if len(s.preds[name]) != len(preds) {
+		return false
+	}
+	want := make(map[string]bool)
+	for _, pred := range preds {
+		want[pred] = true
+	}
+	for _, pred := range s.preds[name] {
+		if !want[pred] {
+			return false
+		}
+	}
+	return true
+}
+
+// newSeq returns a new sequential primitive of the given kind, with nodes
+// specified by pairs of role and node name.
+func (s *seqGraph) newSeq(kind string, pairs ...string) *Sequential {
+	seq := &Sequential{
+		Prim:  kind,
+		Nodes: make(map[string]string),
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		seq.Nodes[pairs[i]] = pairs[i+1]
+	}
+	return seq
+}
+
+// collapse collapses the nodes of the given sequential primitive into its entry
+// node, the name of which is assigned based on the kind of the primitive.
+func (s *seqGraph) collapse(seq *Sequential) {
+	for {
+		seq.Entry = fmt.Sprintf("%s_%d", seq.Prim, s.counts[seq.Prim])
+		s.counts[seq.Prim]++
+		if _, ok := s.succs[seq.Entry]; !ok {
+			break
+		}
+	}
+	var nodes []string
+	for _, role := range roles[seq.Prim] {
+		nodes = append(nodes, seq.Nodes[role])
+	}
+	entry, exit := nodes[0], nodes[len(nodes)-1]
+	del := make(map[string]bool)
+	for _, name := range nodes {
+		del[name] = true
+	}
+	rename := func(name string) string {
+		if del[name] {
+			return seq.Entry
+		}
+		return name
+	}
+	// Redirect edges from external predecessors of the entry node.
+	var preds []string
+	for _, pred := range s.preds[entry] {
+		if del[pred] {
+			continue
+		}
+		preds = append(preds, pred)
+		for i, succ := range s.succs[pred] {
+			s.succs[pred][i] = rename(succ)
+		}
+	}
+	// Redirect edges to successors of the exit node.
+	var succs []string
+	for _, succ := range s.succs[exit] {
+		succs = append(succs, rename(succ))
+		if del[succ] {
+			preds = append(preds, seq.Entry)
+			continue
+		}
+		for i, pred := range s.preds[succ] {
+			s.preds[succ][i] = rename(pred)
+		}
+	}
+	var orig []string
+	for _, name := range nodes {
+		orig = append(orig, s.orig[name]...)
+		delete(s.succs, name)
+		delete(s.preds, name)
+		delete(s.orig, name)
+	}
+	s.succs[seq.Entry] = succs
+	s.preds[seq.Entry] = preds
+	s.orig[seq.Entry] = orig
+	for _, name := range orig {
+		s.cur[name] = seq.Entry
+	}
+	if del[s.entry] {
+		s.entry = seq.Entry
+	}
+}
+
+// label returns the label of the node.
+func label(n graph.Node) string {
+	if n, ok := n.(*cfg.Node); ok {
+		return n.DOTID()
+	}
+	panic(fmt.Sprintf("invalid node type; expected *cfg.Node, got %T", n))
+}
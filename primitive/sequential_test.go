@@ -0,0 +1,150 @@
+package primitive
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/graphism/exp/cfg"
+)
+
+func TestLinearize(t *testing.T) {
+	golden := []struct {
+		in    string
+		prims *Primitives
+		want  []*Sequential
+	}{
+		// if-else statement.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B [label=true]
+				A -> C [label=false]
+				B -> D
+				C -> D
+				D -> E
+			}`,
+			prims: &Primitives{
+				Ifs: []*If{{Cond: "A", Follow: "D"}},
+			},
+			want: []*Sequential{
+				{Prim: "if_else", Nodes: map[string]string{"cond": "A", "body_true": "B", "body_false": "C", "exit": "D"}, Entry: "if_else_0"},
+				{Prim: "seq", Nodes: map[string]string{"entry": "if_else_0", "exit": "E"}, Entry: "seq_0"},
+			},
+		},
+		// Pre-test loop containing an if-statement.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B
+				B -> C [label=true]
+				B -> F [label=false]
+				C -> D [label=true]
+				C -> E [label=false]
+				D -> E
+				E -> B
+			}`,
+			prims: &Primitives{
+				Intervals: map[string][]string{"G1_I2": {"B", "C", "D", "E"}},
+				Loops:     []*Loop{{Type: cfg.LoopTypePreTest, Head: "B", Latch: "E", Follow: "F", Nodes: []string{"C", "D", "E"}}},
+				Ifs:       []*If{{Cond: "C", Follow: "E"}},
+			},
+			want: []*Sequential{
+				{Prim: "if", Nodes: map[string]string{"cond": "C", "body": "D", "exit": "E"}, Entry: "if_0"},
+				{Prim: "pre_loop", Nodes: map[string]string{"cond": "B", "body": "if_0", "exit": "F"}, Entry: "pre_loop_0"},
+				{Prim: "seq", Nodes: map[string]string{"entry": "A", "exit": "pre_loop_0"}, Entry: "seq_0"},
+			},
+		},
+		// Post-test loop.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B
+				B -> C
+				C -> B [label=true]
+				C -> D [label=false]
+			}`,
+			prims: &Primitives{
+				Loops: []*Loop{{Type: cfg.LoopTypePostTest, Head: "B", Latch: "C", Follow: "D", Nodes: []string{"C"}}},
+			},
+			want: []*Sequential{
+				{Prim: "seq", Nodes: map[string]string{"entry": "B", "exit": "C"}, Entry: "seq_0"},
+				{Prim: "post_loop", Nodes: map[string]string{"cond": "seq_0", "exit": "D"}, Entry: "post_loop_0"},
+				{Prim: "seq", Nodes: map[string]string{"entry": "A", "exit": "post_loop_0"}, Entry: "seq_1"},
+			},
+		},
+		// if-statement with a follow node other than its exit node is not
+		// collapsed.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B [label=true]
+				A -> C [label=false]
+				B -> C
+				C -> D
+			}`,
+			prims: &Primitives{
+				Ifs: []*If{{Cond: "A", Follow: "D"}},
+			},
+			want: []*Sequential{
+				{Prim: "seq", Nodes: map[string]string{"entry": "C", "exit": "D"}, Entry: "seq_0"},
+			},
+		},
+		// Switch statement; not collapsed.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B
+				A -> C
+				A -> D
+				B -> E
+				C -> E
+				D -> E
+			}`,
+			prims: &Primitives{
+				Switches: []*Switch{{Head: "A", Follow: "E", Nodes: []string{"A", "B", "C", "D"}}},
+			},
+			want: nil,
+		},
+		// Irreducible graph; only sequences are collapsed.
+		{
+			in: `digraph {
+				E [label=entry]
+				E -> A
+				A -> B [label=true]
+				A -> C [label=false]
+				B -> C
+				C -> B [label=true]
+				C -> D [label=false]
+			}`,
+			prims: &Primitives{},
+			want: []*Sequential{
+				{Prim: "seq", Nodes: map[string]string{"entry": "E", "exit": "A"}, Entry: "seq_0"},
+			},
+		},
+	}
+	for i, gold := range golden {
+		g, err := cfg.ParseBytes([]byte(gold.in))
+		if err != nil {
+			t.Errorf("i=%d: unable to parse graph; %v", i, err)
+			continue
+		}
+		got, err := gold.prims.Linearize(g)
+		if err != nil {
+			t.Errorf("i=%d: unable to linearize primitives; %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, gold.want) {
+			t.Errorf("i=%d: sequential primitives mismatch; expected %v, got %v", i, seqValues(gold.want), seqValues(got))
+		}
+	}
+}
+
+// seqValues returns the values of the given sequential primitives, for
+// printing.
+func seqValues(seqs []*Sequential) []Sequential {
+	var ss []Sequential
+	for _, seq := range seqs {
+		ss = append(ss, *seq)
+	}
+	return ss
+}
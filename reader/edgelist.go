@@ -0,0 +1,61 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseEdgeList parses the given control flow graph in plain edge list format.
+//
+// Each line contains an edge, specified by the source node, the target node and
+// an optional edge label, separated by whitespace. The entry node is specified
+// by a line of the form "entry NODE", and defaults to the source node of the
+// first edge. Lines starting with '#' are comments.
+//
+// Example:
+//
+//    # f
+//    entry A
+//    A B true
+//    A C false
+//    B C
+func parseEdgeList(buf []byte) (*desc, error) {
+	d := newDesc("")
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	for lineNum := 1; s.Scan(); lineNum++ {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if fields[0] == "entry" && len(fields) == 2 {
+			if len(d.entry) > 0 {
+				return nil, errors.Errorf("line %d: entry node already specified; prev %q, new %q", lineNum, d.entry, fields[1])
+			}
+			d.entry = fields[1]
+			d.addNode(fields[1])
+			continue
+		}
+		switch len(fields) {
+		case 2:
+			d.addEdge(fields[0], fields[1], "")
+		case 3:
+			d.addEdge(fields[0], fields[1], fields[2])
+		default:
+			return nil, errors.Errorf("line %d: invalid number of fields in edge %q; expected 2 or 3, got %d", lineNum, line, len(fields))
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(d.entry) == 0 && len(d.edges) > 0 {
+		d.entry = d.edges[0].from
+	}
+	if err := d.validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d, nil
+}
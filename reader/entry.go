@@ -0,0 +1,68 @@
+package reader
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/graph"
+)
+
+// SetEntry sets the entry node of the control flow graph to the node with the
+// given label. If no label is specified, the entry node is left as is if
+// present (i.e. labelled "entry" in DOT files), or located by LocateEntry
+// otherwise.
+func SetEntry(g *cfg.Graph, entryLabel string) error {
+	if len(entryLabel) > 0 {
+		entry, ok := g.NodeWithName(entryLabel)
+		if !ok {
+			return errors.Errorf("unable to locate entry node %q", entryLabel)
+		}
+		g.SetEntry(entry)
+		return nil
+	}
+	if g.Entry() != nil {
+		return nil
+	}
+	entry, err := LocateEntry(g)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	g.SetEntry(entry)
+	return nil
+}
+
+// LocateEntry attempts to locate the entry node of the control flow graph by
+// searching for a single node in the control flow graph with no incoming
+// edges.
+func LocateEntry(g *cfg.Graph) (graph.Node, error) {
+	var candidates []string
+	var entry graph.Node
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node()
+		preds := g.To(n.ID())
+		if preds.Len() == 0 {
+			candidates = append(candidates, label(n))
+			entry = n
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, errors.Errorf("unable to locate entry node; no node without incoming edges")
+	case 1:
+		return entry, nil
+	default:
+		sort.Strings(candidates)
+		return nil, errors.Errorf("unable to locate entry node; more than one candidate %v", candidates)
+	}
+}
+
+// label returns the label of the node.
+func label(n graph.Node) string {
+	if n, ok := n.(*cfg.Node); ok {
+		return n.DOTID()
+	}
+	panic(fmt.Sprintf("invalid node type; expected *cfg.Node, got %T", n))
+}
@@ -0,0 +1,71 @@
+package reader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graphism/exp/cfg"
+)
+
+func TestSetEntry(t *testing.T) {
+	golden := []struct {
+		in    string
+		label string
+		want  string
+		err   string
+	}{
+		// Entry node specified by label.
+		{
+			in:    `digraph { A -> B; B -> C }`,
+			label: "B",
+			want:  "B",
+		},
+		// Unknown entry node label.
+		{
+			in:    `digraph { A -> B }`,
+			label: "X",
+			err:   `unable to locate entry node "X"`,
+		},
+		// Entry node labelled "entry" in DOT file.
+		{
+			in:   `digraph { B [label=entry]; A -> B; B -> A }`,
+			want: "B",
+		},
+		// Single node without incoming edges.
+		{
+			in:   `digraph { B -> C; A -> B; C -> B }`,
+			want: "A",
+		},
+		// No node without incoming edges.
+		{
+			in:  `digraph { A -> B; B -> A }`,
+			err: "no node without incoming edges",
+		},
+		// More than one node without incoming edges.
+		{
+			in:  `digraph { B -> C; A -> C }`,
+			err: "more than one candidate [A B]",
+		},
+	}
+	for i, gold := range golden {
+		g, err := cfg.ParseBytes([]byte(gold.in))
+		if err != nil {
+			t.Errorf("i=%d: unable to parse graph; %v", i, err)
+			continue
+		}
+		err = SetEntry(g, gold.label)
+		if len(gold.err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), gold.err) {
+				t.Errorf("i=%d: error mismatch; expected %q, got %v", i, gold.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("i=%d: unexpected error; %v", i, err)
+			continue
+		}
+		if got := label(g.Entry()); got != gold.want {
+			t.Errorf("i=%d: entry node mismatch; expected %q, got %q", i, gold.want, got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package reader
+
+import (
+	"encoding/xml"
+
+	"github.com/pkg/errors"
+)
+
+// graphML is a GraphML document.
+type graphML struct {
+	// Attribute declarations.
+	Keys []graphMLKey `xml:"key"`
+	// Graphs of the document.
+	Graphs []graphMLGraph `xml:"graph"`
+}
+
+// graphMLKey is a GraphML attribute declaration.
+type graphMLKey struct {
+	// Attribute ID.
+	ID string `xml:"id,attr"`
+	// Domain of the attribute ("node", "edge", ...).
+	For string `xml:"for,attr"`
+	// Attribute name.
+	Name string `xml:"attr.name,attr"`
+}
+
+// graphMLGraph is a GraphML graph.
+type graphMLGraph struct {
+	// Graph ID.
+	ID string `xml:"id,attr"`
+	// Nodes of the graph.
+	Nodes []graphMLElem `xml:"node"`
+	// Edges of the graph.
+	Edges []graphMLElem `xml:"edge"`
+}
+
+// graphMLElem is a GraphML node or edge.
+type graphMLElem struct {
+	// Node ID.
+	ID string `xml:"id,attr"`
+	// Source and target node IDs of edges.
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	// Attribute values.
+	Data []graphMLData `xml:"data"`
+}
+
+// graphMLData is a GraphML attribute value.
+type graphMLData struct {
+	// Attribute ID.
+	Key string `xml:"key,attr"`
+	// Attribute value.
+	Value string `xml:",chardata"`
+}
+
+// parseGraphML parses the given control flow graph in GraphML format.
+//
+// The entry node is the node with a "label" attribute of value "entry", and
+// edge labels are specified by the "label" attribute of edges.
+func parseGraphML(buf []byte) (*desc, error) {
+	var doc graphML
+	if err := xml.Unmarshal(buf, &doc); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(doc.Graphs) != 1 {
+		return nil, errors.Errorf("invalid number of graphs in GraphML document; expected 1, got %d", len(doc.Graphs))
+	}
+	// Map from attribute ID to attribute name, for each domain.
+	names := map[string]map[string]string{
+		"node": make(map[string]string),
+		"edge": make(map[string]string),
+	}
+	for _, key := range doc.Keys {
+		switch key.For {
+		case "node", "edge":
+			names[key.For][key.ID] = key.Name
+		case "all":
+			names["node"][key.ID] = key.Name
+			names["edge"][key.ID] = key.Name
+		}
+	}
+	// label returns the value of the "label" attribute of the given element.
+	label := func(domain string, elem graphMLElem) string {
+		for _, data := range elem.Data {
+			if names[domain][data.Key] == "label" {
+				return data.Value
+			}
+		}
+		return ""
+	}
+	graph := doc.Graphs[0]
+	d := newDesc(graph.ID)
+	for _, n := range graph.Nodes {
+		if d.has[n.ID] {
+			return nil, errors.Errorf("node %q already present", n.ID)
+		}
+		d.addNode(n.ID)
+		if label("node", n) == "entry" {
+			if len(d.entry) > 0 {
+				return nil, errors.Errorf("entry node already specified; prev %q, new %q", d.entry, n.ID)
+			}
+			d.entry = n.ID
+		}
+	}
+	for _, e := range graph.Edges {
+		if !d.has[e.Source] {
+			return nil, errors.Errorf("unable to locate source node %q of edge", e.Source)
+		}
+		if !d.has[e.Target] {
+			return nil, errors.Errorf("unable to locate target node %q of edge", e.Target)
+		}
+		d.addEdge(e.Source, e.Target, label("edge", e))
+	}
+	if err := d.validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d, nil
+}
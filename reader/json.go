@@ -0,0 +1,93 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// jsonGraph is a control flow graph in JSON adjacency list format.
+//
+// Example:
+//
+//    {
+//       "name": "f",
+//       "entry": "A",
+//       "nodes": {
+//          "A": ["B", "C"],
+//          "B": ["C"],
+//          "C": []
+//       },
+//       "labels": {
+//          "A": ["true", "false"]
+//       }
+//    }
+//
+// The successors of 2-way conditionals are ordered by true target and false
+// target, unless labelled explicitly.
+type jsonGraph struct {
+	// Graph name.
+	Name string `json:"name"`
+	// Entry node name.
+	Entry string `json:"entry"`
+	// Map from node name to successor names.
+	Nodes map[string][]string `json:"nodes"`
+	// Map from node name to edge labels, in the order of the successors of the
+	// node.
+	Labels map[string][]string `json:"labels"`
+}
+
+// parseJSON parses the given control flow graph in JSON adjacency list format.
+func parseJSON(buf []byte) (*desc, error) {
+	var g jsonGraph
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&g); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	d := newDesc(g.Name)
+	d.entry = g.Entry
+	// Add entry node first, followed by the remaining nodes in alphabetical
+	// order.
+	if len(g.Entry) > 0 {
+		if _, ok := g.Nodes[g.Entry]; ok {
+			d.addNode(g.Entry)
+		}
+	}
+	var names []string
+	for name := range g.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d.addNode(name)
+	}
+	for _, name := range names {
+		succs := g.Nodes[name]
+		labels, ok := g.Labels[name]
+		if ok && len(labels) != len(succs) {
+			return nil, errors.Errorf("mismatch between number of successors (%d) and edge labels (%d) of node %q", len(succs), len(labels), name)
+		}
+		for i, succ := range succs {
+			if !d.has[succ] {
+				return nil, errors.Errorf("unable to locate successor %q of node %q", succ, name)
+			}
+			label := ""
+			if ok {
+				label = labels[i]
+			}
+			d.addEdge(name, succ, label)
+		}
+	}
+	for name := range g.Labels {
+		if _, ok := g.Nodes[name]; !ok {
+			return nil, errors.Errorf("unable to locate node %q of edge labels", name)
+		}
+	}
+	if err := d.validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d, nil
+}
@@ -0,0 +1,251 @@
+// Package reader parses control flow graphs of various input formats.
+//
+// Supported formats:
+//
+//    * Graphviz DOT files (*.dot, *.gv)
+//    * JSON adjacency lists (*.json)
+//    * plain edge lists (*.edges, *.txt)
+//    * GraphML files (*.graphml, *.xml)
+//
+// Control flow graphs of all formats are converted to DOT and parsed by the cfg
+// package. The entry node is labelled "entry", and the edges of 2-way
+// conditionals are labelled "true" and "false".
+package reader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/pkg/errors"
+)
+
+// Format is an input format of control flow graphs.
+type Format string
+
+// Input formats.
+const (
+	// Auto-detect format based on file extension or content.
+	FormatAuto Format = ""
+	// Graphviz DOT format.
+	FormatDOT Format = "dot"
+	// JSON adjacency list format.
+	FormatJSON Format = "json"
+	// Plain edge list format.
+	FormatEdgeList Format = "edges"
+	// GraphML format.
+	FormatGraphML Format = "graphml"
+)
+
+// ParseFile parses the given control flow graph file, the format of which is
+// detected by file extension or content. The path "-" denotes standard input.
+func ParseFile(path string) (*cfg.Graph, error) {
+	var buf []byte
+	var err error
+	if path == "-" {
+		buf, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		buf, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ParseBytes(buf, Detect(path, buf))
+}
+
+// Parse parses the control flow graph read from r, of the given format.
+func Parse(r io.Reader, format Format) (*cfg.Graph, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ParseBytes(buf, format)
+}
+
+// ParseBytes parses the given control flow graph, of the given format.
+func ParseBytes(buf []byte, format Format) (*cfg.Graph, error) {
+	dot, err := ToDOT(buf, format)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	g, err := cfg.ParseBytes(dot)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return g, nil
+}
+
+// ToDOT converts the given control flow graph, of the given format, to DOT.
+func ToDOT(buf []byte, format Format) ([]byte, error) {
+	if format == FormatAuto {
+		format = Detect("", buf)
+	}
+	var d *desc
+	var err error
+	switch format {
+	case FormatDOT:
+		return buf, nil
+	case FormatJSON:
+		d, err = parseJSON(buf)
+	case FormatEdgeList:
+		d, err = parseEdgeList(buf)
+	case FormatGraphML:
+		d, err = parseGraphML(buf)
+	default:
+		return nil, errors.Errorf("support for input format %q not yet implemented", format)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d.dot(), nil
+}
+
+// Detect returns the format of the given control flow graph, based on the
+// extension of the file name (if any) or the content.
+func Detect(name string, buf []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".dot", ".gv":
+		return FormatDOT
+	case ".json":
+		return FormatJSON
+	case ".edges", ".txt":
+		return FormatEdgeList
+	case ".graphml", ".xml":
+		return FormatGraphML
+	}
+	content := bytes.TrimSpace(buf)
+	switch {
+	case bytes.HasPrefix(content, []byte("{")):
+		return FormatJSON
+	case bytes.HasPrefix(content, []byte("<")):
+		return FormatGraphML
+	}
+	for _, prefix := range []string{"digraph", "strict", "graph", "//", "/*"} {
+		if bytes.HasPrefix(content, []byte(prefix)) {
+			return FormatDOT
+		}
+	}
+	return FormatEdgeList
+}
+
+// desc is a format independent description of a control flow graph.
+type desc struct {
+	// Graph name; or empty if unnamed.
+	name string
+	// Entry node name; or empty if not specified.
+	entry string
+	// Node names, in order of appearance.
+	nodes []string
+	// Edges, in order of appearance.
+	edges []*edge
+	// Set of node names.
+	has map[string]bool
+}
+
+// An edge is a directed edge of a control flow graph.
+type edge struct {
+	// Source and target node names.
+	from, to string
+	// Edge label (e.g. "true" or "false"); or empty if not labelled.
+	label string
+}
+
+// newDesc returns a new description of a control flow graph with the given
+// name.
+func newDesc(name string) *desc {
+	return &desc{
+		name: name,
+		has:  make(map[string]bool),
+	}
+}
+
+// addNode adds the given node to the control flow graph, unless already
+// present.
+func (d *desc) addNode(name string) {
+	if d.has[name] {
+		return
+	}
+	d.has[name] = true
+	d.nodes = append(d.nodes, name)
+}
+
+// addEdge adds the given edge to the control flow graph, and its source and
+// target nodes unless already present.
+func (d *desc) addEdge(from, to, label string) {
+	d.addNode(from)
+	d.addNode(to)
+	d.edges = append(d.edges, &edge{from: from, to: to, label: label})
+}
+
+// dot returns the control flow graph in DOT format.
+func (d *desc) dot() []byte {
+	buf := &bytes.Buffer{}
+	if len(d.name) > 0 {
+		fmt.Fprintf(buf, "digraph %s {\n", strconv.Quote(d.name))
+	} else {
+		buf.WriteString("digraph {\n")
+	}
+	for _, n := range d.nodes {
+		if n == d.entry {
+			fmt.Fprintf(buf, "\t%s [label=entry]\n", strconv.Quote(n))
+			continue
+		}
+		fmt.Fprintf(buf, "\t%s\n", strconv.Quote(n))
+	}
+	for _, e := range d.edges {
+		if len(e.label) > 0 {
+			fmt.Fprintf(buf, "\t%s -> %s [label=%s]\n", strconv.Quote(e.from), strconv.Quote(e.to), strconv.Quote(e.label))
+			continue
+		}
+		fmt.Fprintf(buf, "\t%s -> %s\n", strconv.Quote(e.from), strconv.Quote(e.to))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// validate validates the control flow graph, and labels the edges of 2-way
+// conditionals "true" and "false" in order of appearance unless already
+// labelled.
+func (d *desc) validate() error {
+	if len(d.entry) > 0 && !d.has[d.entry] {
+		return errors.Errorf("unable to locate entry node %q", d.entry)
+	}
+	succs := make(map[string][]*edge)
+	for _, e := range d.edges {
+		succs[e.from] = append(succs[e.from], e)
+	}
+	var names []string
+	for name := range succs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		es := succs[name]
+		if len(es) != 2 {
+			continue
+		}
+		switch {
+		case len(es[0].label) == 0 && len(es[1].label) == 0:
+			es[0].label, es[1].label = "true", "false"
+		case es[0].label == "false" && len(es[1].label) == 0:
+			es[1].label = "true"
+		case es[0].label == "true" && len(es[1].label) == 0:
+			es[1].label = "false"
+		case len(es[0].label) == 0 && es[1].label == "true":
+			es[0].label = "false"
+		case len(es[0].label) == 0 && es[1].label == "false":
+			es[0].label = "true"
+		}
+		if es[0].label == es[1].label {
+			return errors.Errorf("invalid labels of 2-way conditional node %q; both edges labelled %q", name, es[0].label)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package reader
+
+import "testing"
+
+func TestToDOT(t *testing.T) {
+	golden := []struct {
+		name string
+		in   string
+		want string
+	}{
+		// JSON adjacency list; successors ordered by true and false target.
+		{
+			name: "f.json",
+			in:   `{"name": "f", "entry": "A", "nodes": {"A": ["B", "C"], "B": ["C"], "C": []}}`,
+			want: `digraph "f" {
+	"A" [label=entry]
+	"B"
+	"C"
+	"A" -> "B" [label="true"]
+	"A" -> "C" [label="false"]
+	"B" -> "C"
+}
+`,
+		},
+		// JSON adjacency list; explicit edge labels.
+		{
+			name: "f.json",
+			in:   `{"name": "f", "entry": "A", "nodes": {"A": ["C", "B"], "B": ["C"], "C": []}, "labels": {"A": ["false", "true"]}}`,
+			want: `digraph "f" {
+	"A" [label=entry]
+	"B"
+	"C"
+	"A" -> "C" [label="false"]
+	"A" -> "B" [label="true"]
+	"B" -> "C"
+}
+`,
+		},
+		// Edge list; entry node of first edge.
+		{
+			name: "f.edges",
+			in:   "# f\nA B true\nA C\nB C\n",
+			want: `digraph {
+	"A" [label=entry]
+	"B"
+	"C"
+	"A" -> "B" [label="true"]
+	"A" -> "C" [label="false"]
+	"B" -> "C"
+}
+`,
+		},
+		// GraphML, detected by content.
+		{
+			name: "-",
+			in: `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+	<key id="d0" for="node" attr.name="label" attr.type="string"/>
+	<key id="d1" for="edge" attr.name="label" attr.type="string"/>
+	<graph id="f" edgedefault="directed">
+		<node id="A"><data key="d0">entry</data></node>
+		<node id="B"/>
+		<node id="C"/>
+		<edge source="A" target="B"><data key="d1">true</data></edge>
+		<edge source="A" target="C"><data key="d1">false</data></edge>
+		<edge source="B" target="C"/>
+	</graph>
+</graphml>`,
+			want: `digraph "f" {
+	"A" [label=entry]
+	"B"
+	"C"
+	"A" -> "B" [label="true"]
+	"A" -> "C" [label="false"]
+	"B" -> "C"
+}
+`,
+		},
+	}
+	for i, gold := range golden {
+		buf, err := ToDOT([]byte(gold.in), Detect(gold.name, []byte(gold.in)))
+		if err != nil {
+			t.Errorf("i=%d: unable to convert to DOT; %v", i, err)
+			continue
+		}
+		if got := string(buf); got != gold.want {
+			t.Errorf("i=%d: DOT mismatch; expected\n%s\ngot\n%s", i, gold.want, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewmew/cfa/primitive"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
+)
+
+// A cluster is the region of a recovered control flow primitive, nested within
+// the clusters of enclosing primitives.
+type cluster struct {
+	*primitive.Region
+	// Descriptive label of the primitive.
+	label string
+	// Parent cluster; or nil if top-level cluster.
+	parent *cluster
+	// Nested clusters.
+	children []*cluster
+}
+
+// clusters returns the clusters of the primitives recovered from the given
+// control flow graph. The clusters are organized as a forest of properly nested
+// clusters, and the top-level clusters are returned. The second return value
+// holds the primitives which could not be nested and were thus left out.
+//
+// Pre-condition: the nodes of the graph are numbered in reverse post-order.
+func clusters(g *cfg.Graph, prims *primitive.Primitives) (roots, skipped []*cluster) {
+	var cs []*cluster
+	for i, r := range prims.Regions(g) {
+		c := &cluster{Region: r}
+		// Regions are ordered by loops, switch statements and if-statements.
+		switch {
+		case i < len(prims.Loops):
+			c.label = fmt.Sprintf("%v loop %s", prims.Loops[i].Type, prims.Loops[i].Head)
+		case i < len(prims.Loops)+len(prims.Switches):
+			c.label = fmt.Sprintf("switch %s", r.Head)
+		default:
+			c.label = fmt.Sprintf("if %s", r.Head)
+		}
+		cs = append(cs, c)
+	}
+	// Nest clusters, starting with the outermost.
+	less := func(i, j int) bool {
+		return len(cs[i].Nodes) > len(cs[j].Nodes)
+	}
+	sort.SliceStable(cs, less)
+	var accepted []*cluster
+	for _, c := range cs {
+		var parent *cluster
+		valid := true
+		for _, a := range accepted {
+			switch {
+			case a.Contains(c.Region):
+				if parent == nil || len(a.Nodes) <= len(parent.Nodes) {
+					parent = a
+				}
+			case a.Overlaps(c.Region):
+				valid = false
+			}
+		}
+		if !valid {
+			skipped = append(skipped, c)
+			continue
+		}
+		accepted = append(accepted, c)
+		if parent == nil {
+			roots = append(roots, c)
+			continue
+		}
+		c.parent = parent
+		parent.children = append(parent.children, c)
+	}
+	return roots, skipped
+}
+
+// ### [ Helper functions ] ####################################################
+
+// dotID returns the DOT ID of the given node.
+func dotID(n graph.Node) string {
+	return n.(*cfg.Node).DOTID()
+}
+
+// edgeLabel returns the label of the edge (from, to); or the empty string if
+// not labelled.
+func edgeLabel(g *cfg.Graph, from, to graph.Node) string {
+	e, ok := g.Edge(from.ID(), to.ID()).(encoding.Attributer)
+	if !ok {
+		return ""
+	}
+	for _, attr := range e.Attributes() {
+		if attr.Key == "label" {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,206 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewmew/cfa/primitive"
+	"gonum.org/v1/gonum/graph"
+)
+
+// Colours used to highlight the roles of nodes and edges.
+const (
+	// Fill colour of header nodes (loop headers, switch headers and if
+	// conditions).
+	headColor = "lightblue"
+	// Fill colour of loop latch nodes.
+	latchColor = "orange"
+	// Fill colour of follow nodes.
+	followColor = "palegreen"
+	// Colour of unstructured edges.
+	unstructuredColor = "red"
+)
+
+// clusterColors maps from primitive kind to cluster colour.
+var clusterColors = map[string]string{
+	"loop":   "blue",
+	"switch": "purple",
+	"if":     "darkgreen",
+}
+
+// DOT returns the DOT representation of the given control flow graph annotated
+// with its recovered control flow primitives. Each primitive is drawn as a
+// nested cluster subgraph, and the header, latch and follow nodes of primitives
+// and unstructured edges are highlighted using colours.
+func DOT(g *cfg.Graph, prims *primitive.Primitives) string {
+	cfg.InitDFSOrder(g)
+	roots, skipped := clusters(g, prims)
+	a := newAnnotation(g, roots)
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "digraph %q {\n", g.DOTID())
+	for _, c := range skipped {
+		fmt.Fprintf(buf, "\t// Skipped overlapping %s.\n", c.label)
+	}
+	buf.WriteString("\t// Node definitions.\n")
+	for _, c := range roots {
+		a.writeCluster(buf, c, 1)
+	}
+	for _, n := range a.nodes {
+		if a.owner[n.DOTID()] == nil {
+			a.writeNode(buf, n, 1)
+		}
+	}
+	buf.WriteString("\n\t// Edge definitions.\n")
+	for _, n := range a.nodes {
+		for _, succ := range cfg.SortByRevPost(graph.NodesOf(g.From(n.ID()))) {
+			var attrs []string
+			if label := edgeLabel(g, n, succ); len(label) > 0 {
+				attrs = append(attrs, fmt.Sprintf("label=%q", label))
+			}
+			if !a.isStructured(n.DOTID(), succ.DOTID()) {
+				attrs = append(attrs, fmt.Sprintf("color=%s", unstructuredColor), "style=bold")
+			}
+			fmt.Fprintf(buf, "\t%q -> %q", n.DOTID(), succ.DOTID())
+			if len(attrs) > 0 {
+				fmt.Fprintf(buf, " [%s]", strings.Join(attrs, ", "))
+			}
+			buf.WriteString(";\n")
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// annotation tracks the roles of nodes in a control flow graph with respect to
+// its recovered primitives.
+type annotation struct {
+	// Control flow graph.
+	g *cfg.Graph
+	// Nodes of the control flow graph in reverse post-order.
+	nodes []*cfg.Node
+	// Map from node name to the innermost cluster containing the node.
+	owner map[string]*cluster
+	// Map from node name to fill colour.
+	colors map[string]string
+}
+
+// newAnnotation returns a new annotation of the given control flow graph based
+// on the given forest of clusters.
+func newAnnotation(g *cfg.Graph, roots []*cluster) *annotation {
+	a := &annotation{
+		g:      g,
+		nodes:  cfg.SortByRevPost(graph.NodesOf(g.Nodes())),
+		owner:  make(map[string]*cluster),
+		colors: make(map[string]string),
+	}
+	// Record the innermost cluster of each node, by visiting nested clusters
+	// after their parents.
+	var all []*cluster
+	var walk func(c *cluster)
+	walk = func(c *cluster) {
+		all = append(all, c)
+		for n := range c.Nodes {
+			a.owner[n] = c
+		}
+		for _, child := range c.children {
+			walk(child)
+		}
+	}
+	for _, c := range roots {
+		walk(c)
+	}
+	// Follow nodes have the lowest precedence and header nodes the highest.
+	for _, c := range all {
+		if len(c.Follow) > 0 {
+			a.colors[c.Follow] = followColor
+		}
+	}
+	for _, c := range all {
+		if len(c.Latch) > 0 {
+			a.colors[c.Latch] = latchColor
+		}
+	}
+	for _, c := range all {
+		a.colors[c.Head] = headColor
+	}
+	return a
+}
+
+// writeCluster writes the given cluster and its nested clusters as DOT subgraphs
+// to buf, indented by the specified number of tabs.
+func (a *annotation) writeCluster(buf *bytes.Buffer, c *cluster, indent int) {
+	tabs := strings.Repeat("\t", indent)
+	fmt.Fprintf(buf, "%ssubgraph %q {\n", tabs, fmt.Sprintf("cluster_%s_%s", c.Kind, c.Head))
+	fmt.Fprintf(buf, "%s\tlabel=%q;\n", tabs, c.label)
+	fmt.Fprintf(buf, "%s\tcolor=%s;\n", tabs, clusterColors[c.Kind])
+	for _, child := range c.children {
+		a.writeCluster(buf, child, indent+1)
+	}
+	for _, n := range a.nodes {
+		if a.owner[n.DOTID()] == c {
+			a.writeNode(buf, n, indent+1)
+		}
+	}
+	fmt.Fprintf(buf, "%s}\n", tabs)
+}
+
+// writeNode writes the given node to buf, indented by the specified number of
+// tabs.
+func (a *annotation) writeNode(buf *bytes.Buffer, n *cfg.Node, indent int) {
+	var attrs []string
+	if n == a.g.Entry() {
+		attrs = append(attrs, "label=entry")
+	}
+	if color, ok := a.colors[n.DOTID()]; ok {
+		attrs = append(attrs, "style=filled", fmt.Sprintf("fillcolor=%s", color))
+	}
+	fmt.Fprintf(buf, "%s%q", strings.Repeat("\t", indent), n.DOTID())
+	if len(attrs) > 0 {
+		fmt.Fprintf(buf, " [%s]", strings.Join(attrs, ", "))
+	}
+	buf.WriteString(";\n")
+}
+
+// isStructured reports whether the edge (from, to) is explained by the
+// recovered primitives. An edge is unstructured if it enters a primitive at a
+// node other than its header, or if it leaves a primitive to a node other than
+// the follow node of the primitive or the header or follow node of an enclosing
+// primitive (i.e. break and continue).
+func (a *annotation) isStructured(from, to string) bool {
+	// Check edges entering primitives.
+	for c := a.owner[to]; c != nil; c = c.parent {
+		if c.Nodes[from] {
+			break
+		}
+		if to != c.Head {
+			return false
+		}
+	}
+	// Check edges leaving primitives.
+	for c := a.owner[from]; c != nil; c = c.parent {
+		if c.Nodes[to] {
+			break
+		}
+		if !isExitOf(c, to) {
+			return false
+		}
+	}
+	return true
+}
+
+// isExitOf reports whether the given node is a valid exit target of the
+// cluster c; i.e. the follow node of c, or the header or follow node of a
+// cluster enclosing c.
+func isExitOf(c *cluster, n string) bool {
+	if n == c.Follow {
+		return true
+	}
+	for p := c.parent; p != nil; p = p.parent {
+		if n == p.Head || n == p.Follow {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+// Layered graph drawing, as described in K. Sugiyama, S. Tagawa and M. Toda,
+// "Methods for Visual Understanding of Hierarchical System Structures", 1981.
+
+package render
+
+import (
+	"math"
+	"sort"
+)
+
+// Layout dimensions.
+const (
+	// Height of nodes.
+	nodeHeight = 30
+	// Minimum width of nodes.
+	minNodeWidth = 40
+	// Approximate width of each character of node labels.
+	charWidth = 8
+	// Horizontal space between adjacent nodes of a layer.
+	nodeSep = 30
+	// Vertical space between adjacent layers.
+	layerSep = 50
+	// Space surrounding the drawing.
+	margin = 40
+	// Number of sweeps of the crossing reduction phase.
+	sweeps = 8
+)
+
+// A point is a 2D coordinate.
+type point struct {
+	x, y float64
+}
+
+// A layout is a layered drawing of a directed graph.
+type layout struct {
+	// Nodes of the layout; the nodes of the input graph followed by dummy nodes
+	// of edges spanning more than one layer.
+	nodes []*layoutNode
+	// Edges of the input graph.
+	edges []*layoutEdge
+	// Nodes of each layer, ordered from left to right.
+	layers [][]*layoutNode
+	// Dimensions of the drawing.
+	width, height float64
+}
+
+// A layoutNode is a node of a layered drawing.
+type layoutNode struct {
+	// Node label; or empty if dummy node.
+	label string
+	// Layer of the node.
+	layer int
+	// Position of the node within its layer.
+	pos int
+	// Centre of the node.
+	x, y float64
+	// Width of the node; or 0 if dummy node.
+	width float64
+	// Predecessors and successors in the acyclic layered graph.
+	preds, succs []*layoutNode
+}
+
+// A layoutEdge is an edge of a layered drawing.
+type layoutEdge struct {
+	// Source and target nodes.
+	from, to int
+	// Points of the polyline of the edge, from source to target.
+	points []point
+}
+
+// newLayout returns a layered drawing of the directed graph with the given node
+// labels and edges, where each edge is a pair of node indices. The first node
+// is placed in the top layer.
+func newLayout(labels []string, edges [][2]int) *layout {
+	l := &layout{}
+	for _, label := range labels {
+		width := float64(charWidth*len(label) + 20)
+		if width < minNodeWidth {
+			width = minNodeWidth
+		}
+		l.nodes = append(l.nodes, &layoutNode{label: label, width: width})
+	}
+	reversed := l.removeCycles(edges)
+	l.assignLayers(edges, reversed)
+	chains := l.splitLongEdges(edges, reversed)
+	l.orderLayers()
+	l.assignCoords()
+	// Route edges through their dummy nodes.
+	for i, e := range edges {
+		le := &layoutEdge{from: e[0], to: e[1]}
+		from, to := l.nodes[e[0]], l.nodes[e[1]]
+		switch {
+		case e[0] == e[1]:
+			// Self-loop drawn to the right of the node.
+			right := from.x + from.width/2
+			le.points = []point{
+				{right, from.y - nodeHeight/4},
+				{right + nodeSep, from.y - nodeHeight/2},
+				{right + nodeSep, from.y + nodeHeight/2},
+				{right, from.y + nodeHeight/4},
+			}
+		case reversed[i]:
+			// Back edge; routed from the top of the source to the bottom of the
+			// target.
+			le.points = append(le.points, point{from.x, from.y - nodeHeight/2})
+			for j := len(chains[i]) - 1; j >= 0; j-- {
+				d := chains[i][j]
+				le.points = append(le.points, point{d.x, d.y})
+			}
+			le.points = append(le.points, point{to.x, to.y + nodeHeight/2})
+		default:
+			le.points = append(le.points, point{from.x, from.y + nodeHeight/2})
+			for _, d := range chains[i] {
+				le.points = append(le.points, point{d.x, d.y})
+			}
+			le.points = append(le.points, point{to.x, to.y - nodeHeight/2})
+		}
+		l.edges = append(l.edges, le)
+	}
+	return l
+}
+
+// removeCycles makes the graph acyclic by locating the back edges of a depth
+// first traversal, starting at the first node. The returned slice reports for
+// each edge whether it is reversed in the layered graph. Self-loops are treated
+// as reversed edges.
+func (l *layout) removeCycles(edges [][2]int) []bool {
+	succs := make([][]int, len(l.nodes))
+	for i, e := range edges {
+		succs[e[0]] = append(succs[e[0]], i)
+	}
+	reversed := make([]bool, len(edges))
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := make([]int, len(l.nodes))
+	var visit func(n int)
+	visit = func(n int) {
+		state[n] = active
+		for _, i := range succs[n] {
+			m := edges[i][1]
+			switch state[m] {
+			case unvisited:
+				visit(m)
+			case active:
+				reversed[i] = true
+			}
+		}
+		state[n] = done
+	}
+	for n := range l.nodes {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+	return reversed
+}
+
+// assignLayers assigns each node to a layer using the longest path from a source
+// node of the acyclic graph.
+func (l *layout) assignLayers(edges [][2]int, reversed []bool) {
+	inDegree := make([]int, len(l.nodes))
+	succs := make([][]int, len(l.nodes))
+	for i, e := range edges {
+		from, to := e[0], e[1]
+		if from == to {
+			continue
+		}
+		if reversed[i] {
+			from, to = to, from
+		}
+		succs[from] = append(succs[from], to)
+		inDegree[to]++
+	}
+	// Topological order using Kahn's algorithm.
+	var queue []int
+	for n := range l.nodes {
+		if inDegree[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range succs[n] {
+			if layer := l.nodes[n].layer + 1; layer > l.nodes[m].layer {
+				l.nodes[m].layer = layer
+			}
+			inDegree[m]--
+			if inDegree[m] == 0 {
+				queue = append(queue, m)
+			}
+		}
+	}
+}
+
+// splitLongEdges inserts dummy nodes for edges spanning more than one layer,
+// and records the predecessors and successors of each node in the layered
+// graph. The dummy nodes of each edge are returned in top-down order.
+func (l *layout) splitLongEdges(edges [][2]int, reversed []bool) [][]*layoutNode {
+	chains := make([][]*layoutNode, len(edges))
+	for i, e := range edges {
+		if e[0] == e[1] {
+			continue
+		}
+		top, bottom := l.nodes[e[0]], l.nodes[e[1]]
+		if reversed[i] {
+			top, bottom = bottom, top
+		}
+		prev := top
+		for layer := top.layer + 1; layer < bottom.layer; layer++ {
+			d := &layoutNode{layer: layer}
+			l.nodes = append(l.nodes, d)
+			chains[i] = append(chains[i], d)
+			prev.succs = append(prev.succs, d)
+			d.preds = append(d.preds, prev)
+			prev = d
+		}
+		prev.succs = append(prev.succs, bottom)
+		bottom.preds = append(bottom.preds, prev)
+	}
+	for _, n := range l.nodes {
+		for n.layer >= len(l.layers) {
+			l.layers = append(l.layers, nil)
+		}
+		n.pos = len(l.layers[n.layer])
+		l.layers[n.layer] = append(l.layers[n.layer], n)
+	}
+	return chains
+}
+
+// orderLayers reduces edge crossings by repeatedly sorting the nodes of each
+// layer by the barycentre of their neighbours in the adjacent layer, sweeping
+// alternately downwards and upwards.
+func (l *layout) orderLayers() {
+	for sweep := 0; sweep < sweeps; sweep++ {
+		if sweep%2 == 0 {
+			for i := 1; i < len(l.layers); i++ {
+				sortByBarycentre(l.layers[i], func(n *layoutNode) []*layoutNode { return n.preds })
+			}
+		} else {
+			for i := len(l.layers) - 2; i >= 0; i-- {
+				sortByBarycentre(l.layers[i], func(n *layoutNode) []*layoutNode { return n.succs })
+			}
+		}
+	}
+}
+
+// sortByBarycentre sorts the nodes of the given layer by the average position
+// of their neighbours. Nodes without neighbours retain their position.
+func sortByBarycentre(layer []*layoutNode, neighbours func(n *layoutNode) []*layoutNode) {
+	bary := make(map[*layoutNode]float64)
+	for _, n := range layer {
+		ns := neighbours(n)
+		if len(ns) == 0 {
+			bary[n] = float64(n.pos)
+			continue
+		}
+		sum := 0.0
+		for _, m := range ns {
+			sum += float64(m.pos)
+		}
+		bary[n] = sum / float64(len(ns))
+	}
+	less := func(i, j int) bool {
+		return bary[layer[i]] < bary[layer[j]]
+	}
+	sort.SliceStable(layer, less)
+	for pos, n := range layer {
+		n.pos = pos
+	}
+}
+
+// assignCoords assigns coordinates to the nodes of the layout. Nodes are pulled
+// towards the average horizontal position of their neighbours, while retaining
+// the order and separation of nodes within each layer.
+func (l *layout) assignCoords() {
+	// Initial placement from left to right.
+	for _, layer := range l.layers {
+		x := 0.0
+		for _, n := range layer {
+			n.x = x + n.width/2
+			x += n.width + nodeSep
+		}
+	}
+	for sweep := 0; sweep < sweeps; sweep++ {
+		for i := range l.layers {
+			layer := l.layers[i]
+			if sweep%2 == 1 {
+				layer = l.layers[len(l.layers)-1-i]
+			}
+			for _, n := range layer {
+				ns := append(append([]*layoutNode{}, n.preds...), n.succs...)
+				if len(ns) == 0 {
+					continue
+				}
+				sum := 0.0
+				for _, m := range ns {
+					sum += m.x
+				}
+				n.x = sum / float64(len(ns))
+			}
+			separate(layer)
+		}
+	}
+	// Translate the drawing to fit within the margins.
+	minX := math.Inf(1)
+	maxX := math.Inf(-1)
+	for _, n := range l.nodes {
+		minX = math.Min(minX, n.x-n.width/2)
+		maxX = math.Max(maxX, n.x+n.width/2)
+	}
+	if len(l.nodes) == 0 {
+		minX, maxX = 0, 0
+	}
+	for _, n := range l.nodes {
+		n.x += margin - minX
+		n.y = margin + nodeHeight/2 + float64(n.layer)*(nodeHeight+layerSep)
+	}
+	l.width = maxX - minX + 2*margin
+	l.height = float64(len(l.layers))*(nodeHeight+layerSep) - layerSep + 2*margin
+}
+
+// separate moves the nodes of the given layer apart from each other, so that
+// adjacent nodes do not overlap.
+func separate(layer []*layoutNode) {
+	for i := 1; i < len(layer); i++ {
+		prev, n := layer[i-1], layer[i]
+		if min := prev.x + (prev.width+n.width)/2 + nodeSep; n.x < min {
+			n.x = min
+		}
+	}
+}
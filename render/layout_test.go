@@ -0,0 +1,64 @@
+package render
+
+import "testing"
+
+func TestLayout(t *testing.T) {
+	golden := []struct {
+		labels []string
+		edges  [][2]int
+		// Expected layer of each node.
+		want []int
+	}{
+		// if-else statement.
+		{
+			labels: []string{"A", "B", "C", "D"},
+			edges:  [][2]int{{0, 1}, {0, 2}, {1, 3}, {2, 3}},
+			want:   []int{0, 1, 1, 2},
+		},
+		// Pre-test loop with a long exit edge and a back edge.
+		{
+			labels: []string{"entry", "head", "body", "latch", "exit"},
+			edges:  [][2]int{{0, 1}, {1, 2}, {1, 4}, {2, 3}, {3, 1}, {3, 4}},
+			want:   []int{0, 1, 2, 3, 4},
+		},
+		// Self-loop.
+		{
+			labels: []string{"A", "B"},
+			edges:  [][2]int{{0, 1}, {1, 1}},
+			want:   []int{0, 1},
+		},
+	}
+	for i, gold := range golden {
+		l := newLayout(gold.labels, gold.edges)
+		for j, want := range gold.want {
+			if got := l.nodes[j].layer; got != want {
+				t.Errorf("i=%d: layer mismatch of node %q; expected %d, got %d", i, gold.labels[j], want, got)
+			}
+		}
+		// Check that nodes of the same layer do not overlap.
+		for _, layer := range l.layers {
+			for j := 1; j < len(layer); j++ {
+				prev, n := layer[j-1], layer[j]
+				if prev.x+prev.width/2 > n.x-n.width/2 {
+					t.Errorf("i=%d: overlapping nodes %q and %q", i, prev.label, n.label)
+				}
+			}
+		}
+		// Check that edges start at their source node and end at their target
+		// node.
+		if len(l.edges) != len(gold.edges) {
+			t.Errorf("i=%d: number of edges mismatch; expected %d, got %d", i, len(gold.edges), len(l.edges))
+			continue
+		}
+		for _, e := range l.edges {
+			from, to := l.nodes[e.from], l.nodes[e.to]
+			first, last := e.points[0], e.points[len(e.points)-1]
+			if first.x < from.x-from.width/2 || first.x > from.x+from.width/2 {
+				t.Errorf("i=%d: edge %q -> %q does not start at source node", i, from.label, to.label)
+			}
+			if last.x < to.x-to.width/2 || last.x > to.x+to.width/2 {
+				t.Errorf("i=%d: edge %q -> %q does not end at target node", i, from.label, to.label)
+			}
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewmew/cfa/primitive"
+	"gonum.org/v1/gonum/graph"
+)
+
+// Pseudo returns an indented pseudo-code skeleton of the given control flow
+// graph, structured by the recovered control flow primitives. Basic blocks are
+// represented by statements of their names, and unstructured control flow is
+// represented by goto-statements. Statements are indented by tabs.
+//
+// Example:
+//
+//    B1
+//    if (B2) {
+//    	B3
+//    } else {
+//    	B4
+//    }
+//    while (B5) {
+//    	B6
+//    }
+//    B7
+//    return
+func Pseudo(g *cfg.Graph, prims *primitive.Primitives) string {
+	p := newPseudo(g, prims)
+	p.writeCode(label(g.Entry()), "", nil, 0)
+	// Output unstructured basic blocks reached by goto-statements.
+	for i := 0; i < len(p.gotos); i++ {
+		target := p.gotos[i]
+		if p.emitted[target] {
+			continue
+		}
+		p.writeCode(target, "", nil, 0)
+	}
+	buf := &bytes.Buffer{}
+	for _, l := range p.lines {
+		if len(l.node) > 0 && p.labels[l.node] {
+			fmt.Fprintf(buf, "%s%s:\n", strings.Repeat("\t", l.depth), l.node)
+		}
+		fmt.Fprintf(buf, "%s%s\n", strings.Repeat("\t", l.depth), l.text)
+	}
+	return buf.String()
+}
+
+// pseudo is a pseudo-code generator.
+type pseudo struct {
+	// Control flow graph.
+	g *cfg.Graph
+	// Map from header node name to loop primitive.
+	loops map[string]*pseudoLoop
+	// Map from condition node name to follow node name of if-statements.
+	ifs map[string]string
+	// Map from header node name to follow node name of switch statements.
+	switches map[string]string
+	// Lines of output pseudo-code.
+	lines []*line
+	// Emitted basic blocks.
+	emitted map[string]bool
+	// Basic blocks targeted by goto-statements.
+	labels map[string]bool
+	// Targets of goto-statements, in order of occurrence.
+	gotos []string
+}
+
+// pseudoLoop is a loop primitive of the original control flow graph.
+type pseudoLoop struct {
+	// Loop type.
+	typ cfg.LoopType
+	// Header, latch and follow node of the loop.
+	head, latch, follow string
+}
+
+// A line is a line of pseudo-code.
+type line struct {
+	// Indentation depth.
+	depth int
+	// Pseudo-code of the line.
+	text string
+	// Name of the basic block of the line, if a basic block statement.
+	node string
+}
+
+// newPseudo returns a new pseudo-code generator for the given control flow
+// graph and primitives.
+func newPseudo(g *cfg.Graph, prims *primitive.Primitives) *pseudo {
+	p := &pseudo{
+		g:        g,
+		loops:    make(map[string]*pseudoLoop),
+		ifs:      make(map[string]string),
+		switches: make(map[string]string),
+		emitted:  make(map[string]bool),
+		labels:   make(map[string]bool),
+	}
+	if prims == nil {
+		return p
+	}
+	for i, r := range prims.Regions(g) {
+		switch {
+		// Regions are ordered by loops, switch statements and if-statements.
+		case i < len(prims.Loops):
+			p.loops[r.Head] = &pseudoLoop{
+				typ:    prims.Loops[i].Type,
+				head:   r.Head,
+				latch:  r.Latch,
+				follow: r.Follow,
+			}
+		case i < len(prims.Loops)+len(prims.Switches):
+			p.switches[r.Head] = r.Follow
+		default:
+			p.ifs[r.Head] = r.Follow
+		}
+	}
+	return p
+}
+
+// emit outputs the given line of pseudo-code, at the specified indentation
+// depth.
+func (p *pseudo) emit(depth int, format string, args ...interface{}) {
+	p.lines = append(p.lines, &line{depth: depth, text: fmt.Sprintf(format, args...)})
+}
+
+// emitGoto outputs a goto-statement to the given target basic block.
+func (p *pseudo) emitGoto(depth int, target string) {
+	p.emit(depth, "goto %s", target)
+	p.labels[target] = true
+	p.gotos = append(p.gotos, target)
+}
+
+// writeCode outputs the pseudo-code of the basic blocks starting at n, until
+// the stop node is reached. The innermost enclosing loop is specified by loop,
+// if any.
+func (p *pseudo) writeCode(n, stop string, loop *pseudoLoop, depth int) {
+	for len(n) > 0 && n != stop {
+		if loop != nil {
+			switch {
+			// The loop header is part of the loop body of post-test and endless
+			// loops, and thus reached before being emitted.
+			case n == loop.head && p.emitted[n]:
+				p.emit(depth, "continue")
+				return
+			case n == loop.follow:
+				p.emit(depth, "break")
+				return
+			}
+		}
+		if p.emitted[n] {
+			p.emitGoto(depth, n)
+			return
+		}
+		if l, ok := p.loops[n]; ok && (loop == nil || loop.head != n) {
+			p.writeLoop(l, depth)
+			n = l.follow
+			continue
+		}
+		p.emitted[n] = true
+		p.lines = append(p.lines, &line{depth: depth, text: n, node: n})
+		if loop != nil && n == loop.latch && loop.typ == cfg.LoopTypePostTest {
+			// The latch of post-test loops is the loop condition.
+			return
+		}
+		node, _ := p.g.NodeWithName(n)
+		succs := p.succs(node)
+		switch len(succs) {
+		case 0:
+			p.emit(depth, "return")
+			return
+		case 1:
+			n = succs[0]
+		case 2:
+			follow, ok := p.ifs[n]
+			if !ok {
+				// Unstructured 2-way conditional.
+				p.emit(depth, "if (%s) {", n)
+				p.writeJump(succs[0], loop, depth+1)
+				p.emit(depth, "}")
+				n = succs[1]
+				continue
+			}
+			p.writeIf(n, succs[0], succs[1], follow, loop, depth)
+			n = follow
+		default:
+			follow, ok := p.switches[n]
+			if !ok {
+				// Unstructured n-way conditional.
+				p.emit(depth, "switch (%s) {", n)
+				for _, succ := range succs {
+					p.emit(depth, "case %s:", p.caseLabel(node, succ))
+					p.writeJump(succ, loop, depth+1)
+				}
+				p.emit(depth, "}")
+				return
+			}
+			p.emit(depth, "switch (%s) {", n)
+			for _, succ := range succs {
+				p.emit(depth, "case %s:", p.caseLabel(node, succ))
+				p.writeCode(succ, follow, loop, depth+1)
+			}
+			p.emit(depth, "}")
+			n = follow
+		}
+	}
+}
+
+// writeIf outputs the pseudo-code of the if-statement with the given condition
+// node, true and false targets and follow node.
+func (p *pseudo) writeIf(cond, t, f, follow string, loop *pseudoLoop, depth int) {
+	switch {
+	case t == follow:
+		p.emit(depth, "if (!%s) {", cond)
+		p.writeCode(f, follow, loop, depth+1)
+	case f == follow:
+		p.emit(depth, "if (%s) {", cond)
+		p.writeCode(t, follow, loop, depth+1)
+	default:
+		p.emit(depth, "if (%s) {", cond)
+		p.writeCode(t, follow, loop, depth+1)
+		p.emit(depth, "} else {")
+		p.writeCode(f, follow, loop, depth+1)
+	}
+	p.emit(depth, "}")
+}
+
+// writeLoop outputs the pseudo-code of the given loop.
+func (p *pseudo) writeLoop(l *pseudoLoop, depth int) {
+	head, _ := p.g.NodeWithName(l.head)
+	switch l.typ {
+	case cfg.LoopTypePreTest:
+		succs := p.succs(head)
+		if len(succs) != 2 {
+			break
+		}
+		p.emitted[l.head] = true
+		// The loop condition is negated if the true target leaves the loop.
+		body, cond := succs[0], l.head
+		if body == l.follow {
+			body, cond = succs[1], "!"+l.head
+		}
+		p.lines = append(p.lines, &line{depth: depth, text: fmt.Sprintf("while (%s) {", cond), node: l.head})
+		p.writeCode(body, l.head, l, depth+1)
+		p.emit(depth, "}")
+		return
+	case cfg.LoopTypePostTest:
+		p.emit(depth, "do {")
+		p.writeCode(l.head, "", l, depth+1)
+		cond := l.latch
+		if latch, ok := p.g.NodeWithName(l.latch); ok && label(p.g.TrueTarget(latch)) != l.head {
+			cond = "!" + l.latch
+		}
+		p.emit(depth, "} while (%s)", cond)
+		return
+	}
+	// Endless loop; or pre-test loop with unexpected header.
+	p.emit(depth, "for {")
+	p.writeCode(l.head, "", l, depth+1)
+	p.emit(depth, "}")
+}
+
+// writeJump outputs the pseudo-code of a jump to the given target, as taken by
+// unstructured control flow.
+func (p *pseudo) writeJump(target string, loop *pseudoLoop, depth int) {
+	if loop != nil {
+		switch target {
+		case loop.head:
+			p.emit(depth, "continue")
+			return
+		case loop.follow:
+			p.emit(depth, "break")
+			return
+		}
+	}
+	p.emitGoto(depth, target)
+}
+
+// succs returns the successor names of the given node; ordered by true and
+// false target of 2-way conditionals, and by name otherwise.
+func (p *pseudo) succs(n *cfg.Node) []string {
+	if n == nil {
+		return nil
+	}
+	succs := graph.NodesOf(p.g.From(n.ID()))
+	if len(succs) == 2 {
+		return []string{label(p.g.TrueTarget(n)), label(p.g.FalseTarget(n))}
+	}
+	var names []string
+	for _, succ := range succs {
+		names = append(names, dotID(succ))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// caseLabel returns the case label of the edge (n, succ) of an n-way
+// conditional; or the name of the successor if not labelled.
+func (p *pseudo) caseLabel(n *cfg.Node, succ string) string {
+	s, _ := p.g.NodeWithName(succ)
+	if l := edgeLabel(p.g, n, s); len(l) > 0 {
+		return l
+	}
+	return succ
+}
+
+// label returns the name of the given node; or the empty string if nil.
+func label(n *cfg.Node) string {
+	if n == nil {
+		return ""
+	}
+	return n.DOTID()
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewmew/cfa/primitive"
+)
+
+func TestPseudo(t *testing.T) {
+	golden := []struct {
+		in    string
+		prims *primitive.Primitives
+		want  string
+	}{
+		// if-else statement.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B [label=true]
+				A -> C [label=false]
+				B -> D
+				C -> D
+			}`,
+			prims: &primitive.Primitives{
+				Ifs: []*primitive.If{{Cond: "A", Follow: "D"}},
+			},
+			want: "A\nif (A) {\n\tB\n} else {\n\tC\n}\nD\nreturn\n",
+		},
+		// Pre-test loop.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B
+				B -> C [label=true]
+				B -> D [label=false]
+				C -> B
+			}`,
+			prims: &primitive.Primitives{
+				Loops: []*primitive.Loop{{Type: cfg.LoopTypePreTest, Head: "B", Latch: "C", Follow: "D", Nodes: []string{"C"}}},
+			},
+			want: "A\nwhile (B) {\n\tC\n}\nD\nreturn\n",
+		},
+		// Switch statement.
+		{
+			in: `digraph {
+				A [label=entry]
+				A -> B [label=1]
+				A -> C [label=2]
+				A -> D [label=3]
+				B -> E
+				C -> E
+				D -> E
+			}`,
+			prims: &primitive.Primitives{
+				Switches: []*primitive.Switch{{Head: "A", Follow: "E", Nodes: []string{"A", "B", "C", "D"}}},
+			},
+			want: "A\nswitch (A) {\ncase 1:\n\tB\ncase 2:\n\tC\ncase 3:\n\tD\n}\nE\nreturn\n",
+		},
+		// Unstructured control flow; irreducible loop of B and C.
+		{
+			in: `digraph {
+				E [label=entry]
+				E -> A
+				A -> B [label=true]
+				A -> C [label=false]
+				B -> C
+				C -> B [label=true]
+				C -> D [label=false]
+			}`,
+			prims: &primitive.Primitives{},
+			want:  "E\nA\nif (A) {\n\tgoto B\n}\nC:\nC\nif (C) {\n\tgoto B\n}\nD\nreturn\nB:\nB\ngoto C\n",
+		},
+	}
+	for i, gold := range golden {
+		g, err := cfg.ParseBytes([]byte(gold.in))
+		if err != nil {
+			t.Errorf("i=%d: unable to parse graph; %v", i, err)
+			continue
+		}
+		got := Pseudo(g, gold.prims)
+		if got != gold.want {
+			t.Errorf("i=%d: pseudo-code mismatch; expected `%s`, got `%s`", i, gold.want, got)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/graphism/exp/cfg"
+	"github.com/mewmew/cfa/primitive"
+	"gonum.org/v1/gonum/graph"
+)
+
+// Cluster padding.
+const (
+	// Space between a cluster and its innermost nodes.
+	clusterPad = 8
+	// Height of cluster labels.
+	clusterLabelHeight = 14
+)
+
+// SVG returns a standalone SVG drawing of the given control flow graph, using a
+// layered layout. If prims is non-nil, the recovered control flow primitives
+// are drawn as nested clusters, and the header, latch and follow nodes of
+// primitives and unstructured edges are highlighted using colours.
+//
+// The graph may be a derived graph, in which case the nodes of collapsed
+// intervals are listed in the tooltip of each node.
+func SVG(g *cfg.Graph, prims *primitive.Primitives) string {
+	cfg.InitDFSOrder(g)
+	var roots []*cluster
+	if prims != nil {
+		roots, _ = clusters(g, prims)
+	}
+	a := newAnnotation(g, roots)
+	// Layout nodes in reverse post-order, to place the entry node first.
+	index := make(map[string]int)
+	var labels []string
+	for i, n := range a.nodes {
+		index[n.DOTID()] = i
+		labels = append(labels, n.DOTID())
+	}
+	var edges [][2]int
+	for _, n := range a.nodes {
+		for _, succ := range cfg.SortByRevPost(graph.NodesOf(g.From(n.ID()))) {
+			edges = append(edges, [2]int{index[n.DOTID()], index[succ.DOTID()]})
+		}
+	}
+	l := newLayout(labels, edges)
+	// Extend the drawing to fit the padding of the outermost clusters.
+	extra := 0.0
+	for _, c := range roots {
+		if pad := float64((clusterPad + clusterLabelHeight) * (depth(c) + 1)); pad > extra {
+			extra = pad
+		}
+	}
+	width, height := l.width+2*extra, l.height+2*extra
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="%.0f %.0f %.0f %.0f" font-family="monospace" font-size="12">`+"\n", width, height, -extra, -extra, width, height)
+	fmt.Fprintf(buf, "<title>%s</title>\n", html.EscapeString(g.DOTID()))
+	buf.WriteString("<defs>\n")
+	for _, color := range []string{"black", unstructuredColor} {
+		fmt.Fprintf(buf, `<marker id="arrow_%s" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker>`+"\n", color, color)
+	}
+	buf.WriteString("</defs>\n")
+	fmt.Fprintf(buf, `<rect x="%.0f" y="%.0f" width="%.0f" height="%.0f" fill="white"/>`+"\n", -extra, -extra, width, height)
+	// Clusters.
+	for _, c := range roots {
+		writeSVGCluster(buf, l, index, c)
+	}
+	// Edges.
+	for _, e := range l.edges {
+		from, to := labels[e.from], labels[e.to]
+		color, width := "black", 1
+		if !a.isStructured(from, to) {
+			color, width = unstructuredColor, 2
+		}
+		var ps []string
+		for _, p := range e.points {
+			ps = append(ps, fmt.Sprintf("%.1f,%.1f", p.x, p.y))
+		}
+		fmt.Fprintf(buf, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%d" marker-end="url(#arrow_%s)">`, strings.Join(ps, " "), color, width, color)
+		fmt.Fprintf(buf, "<title>%s -> %s</title></polyline>\n", html.EscapeString(from), html.EscapeString(to))
+		if label := edgeLabel(g, a.nodes[e.from], a.nodes[e.to]); len(label) > 0 && len(e.points) >= 2 {
+			p, q := e.points[0], e.points[1]
+			fmt.Fprintf(buf, `<text x="%.1f" y="%.1f" font-size="10" fill="gray">%s</text>`+"\n", (p.x+q.x)/2+3, (p.y+q.y)/2, html.EscapeString(label))
+		}
+	}
+	// Nodes.
+	for i, n := range a.nodes {
+		ln := l.nodes[i]
+		fill := "white"
+		if color, ok := a.colors[n.DOTID()]; ok {
+			fill = color
+		}
+		strokeWidth := 1
+		if n == g.Entry() {
+			strokeWidth = 3
+		}
+		title := n.DOTID()
+		if prims != nil {
+			if nodes := prims.Expand(n.DOTID()); len(nodes) > 1 {
+				title = fmt.Sprintf("%s: %s", title, strings.Join(nodes, " "))
+			}
+		}
+		fmt.Fprintf(buf, `<g><title>%s</title>`, html.EscapeString(title))
+		fmt.Fprintf(buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%d" rx="6" fill="%s" stroke="black" stroke-width="%d"/>`, ln.x-ln.width/2, ln.y-nodeHeight/2, ln.width, nodeHeight, fill, strokeWidth)
+		fmt.Fprintf(buf, `<text x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="central">%s</text></g>`+"\n", ln.x, ln.y, html.EscapeString(n.DOTID()))
+	}
+	buf.WriteString("</svg>\n")
+	return buf.String()
+}
+
+// writeSVGCluster writes the given cluster and its nested clusters as SVG
+// rectangles enclosing the nodes of the clusters.
+func writeSVGCluster(buf *bytes.Buffer, l *layout, index map[string]int, c *cluster) {
+	pad := float64(clusterPad + (clusterPad+clusterLabelHeight)*depth(c))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for name := range c.Nodes {
+		i, ok := index[name]
+		if !ok {
+			continue
+		}
+		n := l.nodes[i]
+		minX = math.Min(minX, n.x-n.width/2)
+		maxX = math.Max(maxX, n.x+n.width/2)
+		minY = math.Min(minY, n.y-nodeHeight/2)
+		maxY = math.Max(maxY, n.y+nodeHeight/2)
+	}
+	if math.IsInf(minX, 1) {
+		return
+	}
+	x, y := minX-pad, minY-pad-clusterLabelHeight
+	width, height := maxX-minX+2*pad, maxY-minY+2*pad+clusterLabelHeight
+	color := clusterColors[c.Kind]
+	fmt.Fprintf(buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="4" fill="none" stroke="%s" stroke-dasharray="4,2"/>`+"\n", x, y, width, height, color)
+	fmt.Fprintf(buf, `<text x="%.1f" y="%.1f" font-size="10" fill="%s">%s</text>`+"\n", x+4, y+clusterLabelHeight-3, color, html.EscapeString(c.label))
+	for _, child := range c.children {
+		writeSVGCluster(buf, l, index, child)
+	}
+}
+
+// depth returns the nesting depth of the clusters contained within c.
+func depth(c *cluster) int {
+	max := 0
+	for _, child := range c.children {
+		if d := depth(child) + 1; d > max {
+			max = d
+		}
+	}
+	return max
+}